@@ -3,8 +3,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"os"
+	"os/signal"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -45,7 +48,8 @@ type Restaurant struct {
 	orders       chan Order
 	waiters      int
 	chefs        int
-	wg           sync.WaitGroup
+	wg           sync.WaitGroup // официанты
+	kitchenWG    sync.WaitGroup // повара
 	tables       map[int]*TableStats
 	tablesMutex  sync.Mutex
 	dishChan     chan string
@@ -102,7 +106,7 @@ func (r *Restaurant) updateTableStats(table int, dishes []string, serveTime time
 }
 
 func (r *Restaurant) chef(id int) {
-	defer r.wg.Done()
+	defer r.kitchenWG.Done()
 	fmt.Printf("Повар #%d готов к работе\n", id)
 
 	for dish := range r.dishChan {
@@ -117,37 +121,39 @@ func (r *Restaurant) chef(id int) {
 	}
 }
 
-func (r *Restaurant) generateOrders(duration time.Duration, maxDishesPerOrder int, tablesCount int) {
-	stopTimer := time.After(duration)
+// generateOrders принимает заказы, пока не отменён ctx, и затем закрывает
+// r.orders. Канал блюд закрывает main, когда официанты передали на кухню всё
+func (r *Restaurant) generateOrders(ctx context.Context, maxDishesPerOrder int, tablesCount int) {
+	defer close(r.orders)
 
-	for {
-		select {
-		case <-stopTimer:
-			close(r.orders)
-			close(r.dishChan)
-			return
-		default:
-			id := int(atomic.AddInt64(&r.orderID, 1))
-			table := rand.Intn(tablesCount) + 1
-			numDishes := rand.Intn(maxDishesPerOrder) + 1
-
-			var dishesList []string
-			for i := 0; i < numDishes; i++ {
-				dishesList = append(dishesList, dishes[rand.Intn(len(dishes))])
-			}
-
-			order := Order{
-				ID:     id,
-				Dishes: dishesList,
-				Table:  table,
-				Time:   time.Now(),
-			}
+	for ctx.Err() == nil {
+		id := int(atomic.AddInt64(&r.orderID, 1))
+		table := rand.Intn(tablesCount) + 1
+		numDishes := rand.Intn(maxDishesPerOrder) + 1
+
+		var dishesList []string
+		for i := 0; i < numDishes; i++ {
+			dishesList = append(dishesList, dishes[rand.Intn(len(dishes))])
+		}
+
+		order := Order{
+			ID:     id,
+			Dishes: dishesList,
+			Table:  table,
+			Time:   time.Now(),
+		}
 
+		select {
+		case r.orders <- order:
 			fmt.Printf("[%s] Новый заказ #%d: %v для стола %d\n",
 				time.Now().Format("15:04:05"), id, dishesList, table)
+		case <-ctx.Done():
+			return
+		}
 
-			r.orders <- order
-			time.Sleep(time.Millisecond * time.Duration(rand.Intn(1000)+500))
+		select {
+		case <-time.After(time.Millisecond * time.Duration(rand.Intn(1000)+500)):
+		case <-ctx.Done():
 		}
 	}
 }
@@ -213,15 +219,17 @@ func main() {
 	fmt.Print("Введите время работы ресторана (в секундах): ")
 	fmt.Scan(&workTime)
 
-	fmt.Println("Ресторан открыт!")
+	fmt.Println("Ресторан открыт! Ctrl+C — закрыться раньше времени")
 
-	restaurant := NewRestaurant(waiters, chefs)
-	restaurant.wg.Add(1)
+	// Ctrl+C или конец рабочего времени — последние заказы: новые не
+	// принимаются, официанты отдают на кухню принятые, повара доготавливают
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(workTime))
+	defer cancel()
 
-	go func() {
-		defer restaurant.wg.Done()
-		restaurant.generateOrders(time.Second*time.Duration(workTime), maxDishes, 10)
-	}()
+	restaurant := NewRestaurant(waiters, chefs)
+	go restaurant.generateOrders(ctx, maxDishes, 10)
 
 	for i := 0; i < waiters; i++ {
 		restaurant.wg.Add(1)
@@ -229,11 +237,17 @@ func main() {
 	}
 
 	for i := 0; i < chefs; i++ {
-		restaurant.wg.Add(1)
+		restaurant.kitchenWG.Add(1)
 		go restaurant.chef(i + 1)
 	}
 
+	<-ctx.Done()
+	stop() // повторное Ctrl+C завершает программу сразу
+	fmt.Println("Последние заказы! Кухня доготавливает то, что уже принято")
+
 	restaurant.wg.Wait()
+	close(restaurant.dishChan)
+	restaurant.kitchenWG.Wait()
 	restaurant.printTableStats()
 	fmt.Println("Ресторан закрыт!")
 }
@@ -0,0 +1,183 @@
+package main
+
+import "fmt"
+
+// === Закрепление столов ===
+// Компанию при посадке закрепляют за официантом, и все дела её стола — заказ,
+// подача, счёт и уборка — ждут в его личной очереди. Официант ведёт не больше
+// max_tables столов сразу; если взять гостей некому, они ждут у хоста, даже
+// когда стол свободен. Уходя на перерыв или со смены, официант передаёт
+// свои столы самым свободным коллегам. Стратегия pool — общий зал без
+// закрепления: любое дело берёт любой свободный официант.
+
+// tableAssignment выбирает официанта для гостей, которых сажают за стол t;
+// ok=false — сейчас их некому обслужить. Выбор не меняет состояние зала:
+// рассадка спрашивает стратегию про каждый подходящий стол.
+type tableAssignment interface {
+	Name() string
+	assign(r *Restaurant, t *Table) (w *StaffMember, ok bool)
+}
+
+// poolAssignment — общий зал; max_tables ограничивает число занятых столов
+// на всех официантов, которые сейчас на месте
+type poolAssignment struct{}
+
+func (poolAssignment) Name() string { return "pool" }
+func (poolAssignment) assign(r *Restaurant, t *Table) (*StaffMember, bool) {
+	if r.cfg.MaxTables == 0 {
+		return nil, true
+	}
+	present, seated := 0, 0
+	for _, m := range r.waiters {
+		if m.present {
+			present++
+		}
+	}
+	for _, t := range r.floor.tables {
+		if t.Party != nil {
+			seated++
+		}
+	}
+	return nil, seated < present*r.cfg.MaxTables
+}
+
+// sectionsAssignment — зал поделён на участки подряд идущих столов между
+// официантами на месте; гости ждут, пока у хозяина участка не освободятся руки
+type sectionsAssignment struct{}
+
+func (sectionsAssignment) Name() string { return "sections" }
+func (sectionsAssignment) assign(r *Restaurant, t *Table) (*StaffMember, bool) {
+	var present []*StaffMember
+	for _, m := range r.waiters {
+		if m.present {
+			present = append(present, m)
+		}
+	}
+	if len(present) == 0 {
+		return nil, false
+	}
+	w := present[(t.ID-1)*len(present)/len(r.floor.tables)]
+	return w, r.canServe(w)
+}
+
+// roundRobinAssignment — гостей отдают официантам по очереди, пропуская
+// тех, кого нет на месте или у кого уже полно столов
+type roundRobinAssignment struct{}
+
+func (roundRobinAssignment) Name() string { return "round_robin" }
+func (roundRobinAssignment) assign(r *Restaurant, t *Table) (*StaffMember, bool) {
+	for i := range r.waiters {
+		m := r.waiters[(r.nextWaiter+i)%len(r.waiters)]
+		if r.canServe(m) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// leastLoadedAssignment — гостей получает официант с наименьшим числом
+// столов, при равенстве — тот, кто обслужил меньше компаний за смену
+type leastLoadedAssignment struct{}
+
+func (leastLoadedAssignment) Name() string { return "least_loaded" }
+func (leastLoadedAssignment) assign(r *Restaurant, t *Table) (*StaffMember, bool) {
+	w := r.leastLoaded(true)
+	return w, w != nil
+}
+
+var tableAssignments = []tableAssignment{poolAssignment{}, sectionsAssignment{}, roundRobinAssignment{}, leastLoadedAssignment{}}
+
+func findAssignment(name string) (tableAssignment, error) {
+	for _, a := range tableAssignments {
+		if a.Name() == name {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("неизвестная стратегия закрепления столов %q", name)
+}
+
+// servable — есть ли сейчас кому обслужить гостей за столом t
+func (r *Restaurant) servable(t *Table) bool {
+	_, ok := r.cfg.Assignment.assign(r, t)
+	return ok
+}
+
+// canServe — может ли официант взять ещё один стол
+func (r *Restaurant) canServe(m *StaffMember) bool {
+	return m.present && (r.cfg.MaxTables == 0 || m.tables < r.cfg.MaxTables)
+}
+
+// leastLoaded — самый свободный официант на месте; capped — не брать тех,
+// у кого уже max_tables столов. nil — подходящих нет.
+func (r *Restaurant) leastLoaded(capped bool) *StaffMember {
+	var best *StaffMember
+	for _, m := range r.waiters {
+		if !m.present || capped && !r.canServe(m) {
+			continue
+		}
+		if best == nil || m.tables < best.tables || m.tables == best.tables && m.parties < best.parties {
+			best = m
+		}
+	}
+	return best
+}
+
+// attach закрепляет стол компании за официантом
+func (r *Restaurant) attach(p *Party, w *StaffMember) {
+	p.Waiter = w
+	w.tables++
+}
+
+// addTask ставит дело в очередь официанта, за которым закреплён стол
+// (в общем зале это общая очередь)
+func (r *Restaurant) addTask(t floorTask) {
+	if w := t.party.Waiter; w != nil {
+		w.tasks.Put(t)
+		return
+	}
+	r.floor.tasks.Put(t)
+}
+
+// setPresent отмечает, что официант вышел на работу или ушёл. Ушедший
+// передаёт столы коллегам, вышедший подхватывает оставшиеся без присмотра
+// и может принять новых гостей.
+func (r *Restaurant) setPresent(m *StaffMember, present bool, reason string) {
+	if m.Role != "waiter" {
+		return
+	}
+	m.present = present
+	r.coverTables(reason)
+	if present {
+		r.seatWaiting()
+	}
+}
+
+// coverTables передаёт столы официантов, которых нет на месте, самым
+// свободным коллегам вместе с делами, ждущими в их очередях. Если на месте
+// никого, столы ждут первого, кто выйдет.
+func (r *Restaurant) coverTables(reason string) {
+	for _, t := range r.floor.tables {
+		p := t.Party
+		if p == nil || p.Waiter == nil || p.Waiter.present {
+			continue
+		}
+		w := r.leastLoaded(false)
+		if w == nil {
+			return
+		}
+		from := p.Waiter
+		from.tables--
+		r.attach(p, w)
+		var kept []floorTask
+		for _, task := range from.tasks.items {
+			if task.party == p {
+				w.tasks.Put(task)
+			} else {
+				kept = append(kept, task)
+			}
+		}
+		from.tasks.items = kept
+		r.logf("[%s] Стол %d официанта %d перешёл к официанту %d\n", formatTime(r.clock.now), t.ID, from.ID, w.ID)
+		r.emit(Event{Type: evTableReassigned, Party: p.ID, Table: t.ID, Waiter: w.ID, Reason: reason})
+	}
+}
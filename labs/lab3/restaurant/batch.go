@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// === Серии прогонов ===
+// Одна смена — лишь одна случайная выборка. go run . batch [флаги]
+// проводит серию независимых смен с seed, seed+1, ... параллельно на всех ядрах
+// и оценивает среднее, разброс и 95% доверительный интервал показателей.
+
+// shiftOutcome — итоги одной смены
+type shiftOutcome struct {
+	Revenue     float64
+	LostRevenue float64
+	Parties     int
+	LostParties int
+	Served      int
+	P50Total    time.Duration // от посадки до подачи
+	P90Total    time.Duration
+	P99Total    time.Duration
+	Hours       float64
+
+	Offsite       int // заказов навынос и с доставкой отдано
+	OffsiteOnTime int
+
+	Booked       int
+	BookedSeated int
+
+	WaiterSpread float64 // разброс загрузки официантов, %
+}
+
+func (s *StatsSnapshot) outcome() shiftOutcome {
+	out := shiftOutcome{
+		LostRevenue: s.seating.LostRevenue,
+		Parties:     s.seating.PartiesArrived,
+		LostParties: s.seating.PartiesTurnedAway + s.seating.PartiesWalkedOut + s.seating.OrdersAbandoned,
+		Served:      len(s.orders),
+		Hours:       s.run.Close.Sub(s.run.Open).Hours(),
+	}
+	var total []time.Duration
+	for _, o := range s.orders {
+		out.Revenue += o.Price
+		total = append(total, o.TotalTime())
+	}
+	out.Booked, out.BookedSeated = s.reservations.Booked, s.reservations.Seated
+	out.WaiterSpread = s.balanceReport(s.staffReports()).UtilizationCVPct
+	for _, st := range s.offsite {
+		out.Revenue += st.Revenue
+		out.Offsite += st.Handed
+		out.OffsiteOnTime += st.OnTime
+	}
+	total = sortDurations(total)
+	out.P50Total = percentile(total, 50)
+	out.P90Total = percentile(total, 90)
+	out.P99Total = percentile(total, 99)
+	return out
+}
+
+func (o shiftOutcome) LostPct() float64 {
+	if o.Parties == 0 {
+		return 0
+	}
+	return 100 * float64(o.LostParties) / float64(o.Parties)
+}
+
+// replicate проводит runs смен на workers горутинах. Смена i всегда
+// использует seed+i, поэтому итог не зависит от числа ядер.
+func replicate(cfg SimConfig, runs int, seed int64, workers int) []shiftOutcome {
+	outcomes := make([]shiftOutcome, runs)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run := cfg
+				run.Seed = seed + int64(i)
+				outcomes[i] = simulate(run).Snapshot().outcome()
+			}
+		}()
+	}
+	for i := 0; i < runs; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return outcomes
+}
+
+// estimate — среднее по прогонам, стандартное отклонение и полуширина
+// 95% доверительного интервала
+type estimate struct {
+	Mean float64
+	SD   float64
+	Half float64
+}
+
+func (e estimate) String() string {
+	return fmt.Sprintf("%.1f ± %.1f", e.Mean, e.Half)
+}
+
+// tQuantiles — квантиль 0.975 распределения Стьюдента для 1..30 степеней свободы
+var tQuantiles = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func newEstimate(xs []float64) estimate {
+	n := len(xs)
+	if n == 0 {
+		return estimate{}
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(n)
+	if n == 1 {
+		return estimate{Mean: mean}
+	}
+	var sq float64
+	for _, x := range xs {
+		sq += (x - mean) * (x - mean)
+	}
+	sd := math.Sqrt(sq / float64(n-1))
+	t := 1.96
+	if n-1 <= len(tQuantiles) {
+		t = tQuantiles[n-2]
+	}
+	return estimate{Mean: mean, SD: sd, Half: t * sd / math.Sqrt(float64(n))}
+}
+
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	scenario := addScenarioFlags(fs, true)
+	runs := fs.Int("runs", 30, "количество смен")
+	workers := fs.Int("workers", runtime.NumCPU(), "сколько смен считать параллельно")
+	fs.Parse(args)
+
+	cfg, err := scenario.config()
+	exitOnScenarioError(err)
+	if *runs < 1 || *workers < 1 {
+		fmt.Fprintln(os.Stderr, "Ошибка: нужна хотя бы одна смена и один поток")
+		os.Exit(2)
+	}
+
+	fmt.Printf("Серия из %d смен (seed %d..%d): %d поваров, %d официантов, %d столов, политика %s, терпение %s\n",
+		*runs, cfg.Seed, cfg.Seed+int64(*runs)-1,
+		cfg.Chefs, cfg.Waiters, cfg.Tables, cfg.Policy.Name(), cfg.Patience)
+	outcomes := replicate(cfg, *runs, cfg.Seed, *workers)
+	printBatch(outcomes)
+}
+
+func printBatch(outcomes []shiftOutcome) {
+	var revenue, served, lostPct, lostRevenue, p50, p90, p99, onTime, fill, spread []float64
+	for _, o := range outcomes {
+		spread = append(spread, o.WaiterSpread)
+		if o.Booked > 0 {
+			fill = append(fill, percentOf(float64(o.BookedSeated), float64(o.Booked)))
+		}
+		if o.Offsite > 0 {
+			onTime = append(onTime, percentOf(float64(o.OffsiteOnTime), float64(o.Offsite)))
+		}
+		revenue = append(revenue, o.Revenue)
+		served = append(served, float64(o.Served))
+		lostPct = append(lostPct, o.LostPct())
+		lostRevenue = append(lostRevenue, o.LostRevenue)
+		// перцентили есть только у смен, где подали хоть один заказ
+		if o.Served > 0 {
+			p50 = append(p50, minutes(o.P50Total))
+			p90 = append(p90, minutes(o.P90Total))
+			p99 = append(p99, minutes(o.P99Total))
+		}
+	}
+
+	separator := "+----------------------------+------------+------------+-------------------------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-26s | %-10s | %-10s | %-23s |\n", "Показатель", "Среднее", "Ст. откл.", "95% ДИ")
+	fmt.Println(separator)
+	type metric struct {
+		label string
+		xs    []float64
+	}
+	rows := []metric{
+		{"Выручка, руб.", revenue},
+		{"Подано заказов", served},
+		{"Доля отказов, %", lostPct},
+		{"Потерянная выручка, руб.", lostRevenue},
+		{"Обслуживание p50, мин", p50},
+		{"Обслуживание p90, мин", p90},
+		{"Обслуживание p99, мин", p99},
+		{"Разброс загрузки офиц., %", spread},
+	}
+	if len(onTime) > 0 {
+		rows = append(rows, metric{"Вынос/доставка вовремя, %", onTime})
+	}
+	if len(fill) > 0 {
+		rows = append(rows, metric{"Заполнение брони, %", fill})
+	}
+	for _, row := range rows {
+		e := newEstimate(row.xs)
+		interval := fmt.Sprintf("%.1f — %.1f", e.Mean-e.Half, e.Mean+e.Half)
+		fmt.Printf("| %-26s | %10.1f | %10.1f | %-23s |\n", row.label, e.Mean, e.SD, interval)
+	}
+	fmt.Println(separator)
+	if len(p90) < len(outcomes) {
+		fmt.Printf("Смен без поданных заказов: %d — в перцентили не вошли\n", len(outcomes)-len(p90))
+	}
+}
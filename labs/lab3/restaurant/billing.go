@@ -0,0 +1,184 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// === Касса ===
+// Счёт выставляется, когда компания поела. Цены меню включают НДС; скидки
+// не суммируются — позиция получает либо комбо, либо счастливый час.
+
+// Billing — правила расчёта со гостями
+type Billing struct {
+	VAT             float64 // ставка НДС, включённого в цены
+	ServiceCharge   float64 // доля от суммы после скидок
+	ServiceMinParty int     // сервисный сбор только с компаний от стольких гостей; 0 — со всех
+	HappyHour       *HappyHour
+	Combos          []Combo
+	Tips            TipModel
+	SplitChance     float64 // вероятность, что компания платит каждый за себя
+	CardShare       float64 // доля платежей картой, остальные — наличными
+}
+
+// HappyHour — скидка на позиции, заказанные в указанные часы
+type HappyHour struct {
+	From     time.Duration // от полуночи
+	To       time.Duration
+	Discount float64
+	Stations []string // пусто — на всё меню
+}
+
+// Combo — скидка на набор блюд из одного заказа
+type Combo struct {
+	Name     string   `json:"name"`
+	Dishes   []string `json:"dishes"`
+	Discount float64  `json:"discount"`
+}
+
+// TipModel — чаевые зависят от того, как быстро подали заказ: полная ставка,
+// если от посадки до подачи прошло не больше Target, и ноль начиная с Zero
+type TipModel struct {
+	Rate   float64
+	Chance float64 // доля компаний, которые вообще оставляют чаевые
+	Target time.Duration
+	Zero   time.Duration
+}
+
+type CheckLine struct {
+	Dish     string  `json:"dish"`
+	Price    float64 `json:"price"`
+	Discount float64 `json:"discount,omitempty"`
+	Promo    string  `json:"promo,omitempty"`
+}
+
+type Payment struct {
+	Method string  `json:"method"` // card или cash
+	Amount float64 `json:"amount"`
+	Tip    float64 `json:"tip,omitempty"`
+}
+
+// Check — счёт одного визита компании
+type Check struct {
+	Lines     []CheckLine `json:"lines"`
+	Gross     float64     `json:"gross"` // по ценам меню
+	Discounts float64     `json:"discounts"`
+	Service   float64     `json:"service"`
+	VAT       float64     `json:"vat"`   // в том числе в Total
+	Total     float64     `json:"total"` // к оплате без чаевых
+	Tip       float64     `json:"tip"`
+	Payments  []Payment   `json:"payments"`
+}
+
+// NetRevenue — то, что остаётся ресторану: без НДС и без чаевых
+func (c *Check) NetRevenue() float64 {
+	return c.Total - c.VAT
+}
+
+func roundMoney(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+func (h *HappyHour) active(t, day time.Time) bool {
+	from, to := day.Add(h.From), day.Add(h.To)
+	if h.To <= h.From {
+		to = to.Add(24 * time.Hour)
+	}
+	return !t.Before(from) && t.Before(to)
+}
+
+func (h *HappyHour) covers(station string) bool {
+	return len(h.Stations) == 0 || hasSkill(h.Stations, station)
+}
+
+// bill выставляет счёт компании и решает, как она расплатится
+func (r *Restaurant) bill(p *Party) *Check {
+	b := r.cfg.Billing
+	order := p.Order
+	check := &Check{}
+	for _, dish := range order.Dishes {
+		check.Lines = append(check.Lines, CheckLine{Dish: dish.Name, Price: dish.BasePrice})
+		check.Gross += dish.BasePrice
+	}
+
+	// комбо собираются из ещё не задействованных позиций, пока хватает блюд
+	for _, combo := range b.Combos {
+		for {
+			var picked []int
+			for _, name := range combo.Dishes {
+				for i, line := range check.Lines {
+					if line.Promo == "" && line.Dish == name && !containsInt(picked, i) {
+						picked = append(picked, i)
+						break
+					}
+				}
+			}
+			if len(picked) < len(combo.Dishes) {
+				break
+			}
+			for _, i := range picked {
+				check.Lines[i].Promo = combo.Name
+				check.Lines[i].Discount = roundMoney(check.Lines[i].Price * combo.Discount)
+			}
+		}
+	}
+	if h := b.HappyHour; h != nil && h.active(order.StartTime, r.openTime.Add(-r.cfg.OpenAt)) {
+		for i, dish := range order.Dishes {
+			if check.Lines[i].Promo == "" && h.covers(dish.Station) {
+				check.Lines[i].Promo = "happy_hour"
+				check.Lines[i].Discount = roundMoney(dish.BasePrice * h.Discount)
+			}
+		}
+	}
+	for _, line := range check.Lines {
+		check.Discounts += line.Discount
+	}
+	check.Discounts = roundMoney(check.Discounts)
+
+	net := check.Gross - check.Discounts
+	if b.ServiceMinParty == 0 || p.Size >= b.ServiceMinParty {
+		check.Service = roundMoney(net * b.ServiceCharge)
+	}
+	check.Total = roundMoney(net + check.Service)
+	check.VAT = roundMoney(check.Total * b.VAT / (1 + b.VAT))
+
+	// чаевые: чем дольше ждали заказ, тем меньше
+	if r.billRng.Float64() < b.Tips.Chance {
+		rate := b.Tips.Rate
+		if wait := order.EndTime.Sub(order.SeatedAt); wait >= b.Tips.Zero {
+			rate = 0
+		} else if wait > b.Tips.Target {
+			rate *= float64(b.Tips.Zero-wait) / float64(b.Tips.Zero-b.Tips.Target)
+		}
+		check.Tip = roundMoney(check.Total * rate)
+	}
+
+	payers := 1
+	if p.Size > 1 && r.billRng.Float64() < b.SplitChance {
+		payers = p.Size
+	}
+	amountLeft, tipLeft := check.Total, check.Tip
+	for i := 0; i < payers; i++ {
+		pay := Payment{Method: "cash", Amount: amountLeft, Tip: tipLeft}
+		if i < payers-1 {
+			pay.Amount = roundMoney(check.Total / float64(payers))
+			pay.Tip = roundMoney(check.Tip / float64(payers))
+		}
+		amountLeft = roundMoney(amountLeft - pay.Amount)
+		tipLeft = roundMoney(tipLeft - pay.Tip)
+		if r.billRng.Float64() < b.CardShare {
+			pay.Method = "card"
+		}
+		check.Payments = append(check.Payments, pay)
+	}
+	return check
+}
+
+func containsInt(xs []int, x int) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// === Командная строка ===
+// Без аргументов программа спрашивает состав смены и проводит её в реальном
+// времени. Подкоманды: run, batch, optimize, analyze, replay, timeline.
+// go.mod у лабораторных нет, поэтому пакет собирается в режиме GOPATH:
+//
+//	GO111MODULE=off go run . run -scenario scenarios/weekday.json -fast
+//	GO111MODULE=off go test -race .
+
+// scenarioFlags — флаги сценария, общие для run, batch и optimize. Флаг,
+// заданный явно, переопределяет значение из файла сценария.
+type scenarioFlags struct {
+	fs    *flag.FlagSet
+	file  string
+	sc    Scenario
+	staff bool // флаги -chefs и -waiters задают состав; у optimize это диапазоны
+}
+
+func addScenarioFlags(fs *flag.FlagSet, staff bool) *scenarioFlags {
+	f := &scenarioFlags{fs: fs, staff: staff}
+	d := defaultScenario()
+	fs.StringVar(&f.file, "scenario", "", "файл сценария JSON")
+	if staff {
+		fs.IntVar(&f.sc.Chefs, "chefs", d.Chefs, "количество поваров")
+		fs.IntVar(&f.sc.Waiters, "waiters", d.Waiters, "количество официантов")
+	}
+	fs.IntVar(&f.sc.Tables, "tables", d.Tables, "количество столов")
+	fs.StringVar(&f.sc.Menu, "menu", d.Menu, "файл меню JSON")
+	fs.StringVar(&f.sc.Open, "open", d.Open, "время открытия")
+	fs.StringVar(&f.sc.Duration, "duration", d.Duration, "длина дня")
+	fs.StringVar(&f.sc.Arrivals.Kind, "arrivals", d.Arrivals.Kind, "модель прихода гостей (uniform, poisson)")
+	fs.Float64Var(&f.sc.Arrivals.GuestsPerTable, "guests-per-table", d.Arrivals.GuestsPerTable,
+		"в среднем гостей в час на стол")
+	fs.StringVar(&f.sc.Patience, "patience", d.Patience, "терпение гостей: распределение и среднее в минутах")
+	fs.StringVar(&f.sc.Policy, "policy", d.Policy, "политика кухни (fifo, spt, edd, batch, vip)")
+	fs.StringVar(&f.sc.Assignment, "assignment", d.Assignment,
+		"закрепление столов за официантами (pool, sections, round_robin, least_loaded)")
+	fs.IntVar(&f.sc.MaxTables, "max-tables", d.MaxTables, "столов на официанта одновременно; 0 — без ограничения")
+	fs.Float64Var(&f.sc.Booking.PerHour, "reservations", d.Booking.PerHour, "броней в час; 0 — только гости с улицы")
+	fs.Int64Var(&f.sc.Seed, "seed", d.Seed, "seed; 0 — случайный")
+	return f
+}
+
+// config читает файл сценария, накладывает явно заданные флаги и проверяет результат
+func (f *scenarioFlags) config() (SimConfig, error) {
+	sc := defaultScenario()
+	if f.file != "" {
+		var err error
+		if sc, err = loadScenario(f.file); err != nil {
+			return SimConfig{}, err
+		}
+	}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "chefs":
+			if f.staff {
+				sc.Chefs = f.sc.Chefs
+			}
+		case "waiters":
+			if f.staff {
+				sc.Waiters = f.sc.Waiters
+			}
+		case "tables":
+			sc.Tables = f.sc.Tables
+		case "menu":
+			sc.Menu = f.sc.Menu
+		case "open":
+			sc.Open = f.sc.Open
+		case "duration":
+			sc.Duration = f.sc.Duration
+		case "arrivals":
+			sc.Arrivals.Kind = f.sc.Arrivals.Kind
+		case "guests-per-table":
+			sc.Arrivals.GuestsPerTable = f.sc.Arrivals.GuestsPerTable
+		case "patience":
+			sc.Patience = f.sc.Patience
+		case "policy":
+			sc.Policy = f.sc.Policy
+		case "assignment":
+			sc.Assignment = f.sc.Assignment
+		case "max-tables":
+			sc.MaxTables = f.sc.MaxTables
+		case "reservations":
+			sc.Booking.PerHour = f.sc.Booking.PerHour
+		case "seed":
+			sc.Seed = f.sc.Seed
+		}
+	})
+	return sc.config()
+}
+
+// exitOnScenarioError печатает все ошибки сценария и завершает программу
+func exitOnScenarioError(err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Ошибки в сценарии:")
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(os.Stderr, "  - %s\n", line)
+	}
+	os.Exit(2)
+}
+
+// runScenario проводит одну смену по сценарию без диалога
+func runScenario(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	scenario := addScenarioFlags(fs, true)
+	exportDir := fs.String("export", "", "каталог для выгрузки JSON/CSV")
+	eventsPath := fs.String("events", "", "файл для журнала событий")
+	timelineDir := fs.String("timeline", "", "каталог для хронологии кухни (SVG, HTML, trace.json)")
+	dashboardAddr := fs.String("dashboard", "", "адрес веб-панели, например :8080")
+	fast := fs.Bool("fast", false, "не выдерживать паузы реального времени")
+	quiet := fs.Bool("quiet", false, "не печатать ход смены")
+	fs.Parse(args)
+
+	cfg, err := scenario.config()
+	exitOnScenarioError(err)
+	cfg.Realtime = !*fast
+	cfg.Quiet = *quiet
+	runShift(cfg, *exportDir, *eventsPath, *timelineDir, *dashboardAddr)
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "timeline":
+			runTimeline(os.Args[2:])
+			return
+		case "run":
+			runScenario(os.Args[2:])
+			return
+		case "batch":
+			runBatch(os.Args[2:])
+			return
+		case "optimize":
+			runOptimize(os.Args[2:])
+			return
+		case "analyze":
+			runAnalyze(os.Args[2:])
+			return
+		}
+	}
+
+	var numChefs, numWaiters, numTables, maxTablesPerWaiter int
+
+	for {
+		fmt.Print("Введите количество поваров (<=10): ")
+		fmt.Scan(&numChefs)
+		if numChefs <= 10 && numChefs > 0 {
+			break
+		}
+		fmt.Println("Некорректное значение! Поваров должно быть от 1 до 10.")
+	}
+
+	for {
+		fmt.Printf("Введите количество официантов (<=15, но не меньше %d): ", numChefs)
+		fmt.Scan(&numWaiters)
+		if numWaiters <= 15 && numWaiters >= numChefs {
+			break
+		}
+		fmt.Printf("Некорректное значение! Официантов должно быть от %d до 15.\n", numChefs)
+	}
+
+	for {
+		fmt.Printf("Введите количество столов (<=20, но не меньше %d): ", numWaiters)
+		fmt.Scan(&numTables)
+		if numTables <= 20 && numTables >= numWaiters {
+			break
+		}
+		fmt.Printf("Некорректное значение! Столиков должно быть от %d до 20.\n", numWaiters)
+	}
+
+	for {
+		fmt.Printf("Введите максимальное количество столов на одного официанта (<=5): ")
+		fmt.Scan(&maxTablesPerWaiter)
+		if maxTablesPerWaiter <= 5 && maxTablesPerWaiter > 0 {
+			break
+		}
+		fmt.Println("Некорректное значение! Количество столов должно быть от 1 до 5.")
+	}
+
+	var assignment string
+	for {
+		fmt.Print("Выберите закрепление столов (pool, sections, round_robin, least_loaded): ")
+		fmt.Scan(&assignment)
+		_, err := findAssignment(assignment)
+		if err == nil {
+			break
+		}
+		fmt.Println("Некорректное значение!", err)
+	}
+
+	var patience string
+	for {
+		var kind string
+		var minutes int
+		fmt.Print("Введите терпение гостей — распределение (fixed, uniform, exp, normal) и среднее в минутах: ")
+		fmt.Scan(&kind, &minutes)
+		_, err := parseDistribution(kind, time.Duration(minutes)*time.Minute)
+		if err == nil && minutes > 0 {
+			patience = fmt.Sprintf("%s:%d", kind, minutes)
+			break
+		}
+		fmt.Println("Некорректное значение! Например: exp 40")
+	}
+
+	var policy string
+	for {
+		fmt.Print("Выберите политику кухни (fifo, spt, edd, batch, vip): ")
+		fmt.Scan(&policy)
+		_, err := findPolicy(policy)
+		if err == nil {
+			break
+		}
+		fmt.Println("Некорректное значение!", err)
+	}
+
+	var exportDir string
+	fmt.Print("Введите каталог для выгрузки JSON/CSV (- если не нужно): ")
+	fmt.Scan(&exportDir)
+
+	var eventsPath string
+	fmt.Print("Введите файл для журнала событий (- если не нужно): ")
+	fmt.Scan(&eventsPath)
+
+	var dashboardAddr string
+	fmt.Print("Введите адрес веб-панели, например :8080 (- если не нужна): ")
+	fmt.Scan(&dashboardAddr)
+
+	sc := defaultScenario()
+	sc.Chefs = numChefs
+	sc.Waiters = numWaiters
+	sc.Tables = numTables
+	sc.Patience = patience
+	sc.Policy = policy
+	sc.Assignment = assignment
+	sc.MaxTables = maxTablesPerWaiter
+	cfg, err := sc.config()
+	exitOnScenarioError(err)
+	cfg.Realtime = true
+	runShift(cfg, exportDir, eventsPath, "", dashboardAddr)
+}
+
+// runShift проводит смену с выводом хода смены, итоговой статистикой
+// и, по желанию, выгрузкой, журналом событий и веб-панелью. Ctrl+C
+// объявляет последние заказы: смена дорабатывает начатое без ожидания
+// в реальном времени и печатает статистику; повторное Ctrl+C завершает
+// программу сразу
+func runShift(cfg SimConfig, exportDir, eventsPath, timelineDir, dashboardAddr string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	restaurant := newRestaurant(cfg)
+	fmt.Printf("Ресторан открывается в %s и закрывается в %s (seed %d)\n",
+		formatTime(restaurant.openTime), formatTime(restaurant.closeTime), cfg.Seed)
+
+	if dashboardAddr != "-" && dashboardAddr != "" {
+		restaurant.dashboard = newDashboard(restaurant.stats)
+		server := &http.Server{Addr: dashboardAddr, Handler: restaurant.dashboard.handler()}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "Ошибка веб-панели: %v\n", err)
+			}
+		}()
+		fmt.Printf("Веб-панель: http://%s/\n", dashboardURLHost(dashboardAddr))
+	}
+
+	var eventsOut *bufio.Writer
+	var sinks []io.Writer
+	if eventsPath != "-" && eventsPath != "" {
+		f, err := os.Create(eventsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка при создании журнала: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		sinks = append(sinks, f)
+	}
+	// хронологию кухни строим по тому же журналу, что пишется в файл
+	var eventLog bytes.Buffer
+	if timelineDir != "-" && timelineDir != "" {
+		sinks = append(sinks, &eventLog)
+	}
+	if len(sinks) > 0 {
+		eventsOut = bufio.NewWriter(io.MultiWriter(sinks...))
+		restaurant.events = json.NewEncoder(eventsOut)
+	}
+
+	progress, finish := context.WithCancel(ctx)
+	// с веб-панелью таблицы в консоли не нужны — они только уводят журнал с экрана
+	go func() {
+		if restaurant.dashboard != nil {
+			return
+		}
+		realInterval := 5 * time.Second
+		tickTime := time.NewTimer(realInterval)
+		defer tickTime.Stop()
+
+		for {
+			select {
+			case <-tickTime.C:
+				fmt.Println("\n=== Текущая статистика ===")
+				restaurant.stats.Snapshot().printAll(false)
+				tickTime.Reset(realInterval)
+			case <-progress.Done():
+				return
+			}
+		}
+	}()
+
+	restaurant.run(ctx)
+	finish()
+
+	if eventsOut != nil {
+		if err := eventsOut.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка при записи журнала: %v\n", err)
+		} else if eventsPath != "-" && eventsPath != "" {
+			fmt.Printf("Журнал событий сохранён в %s\n", eventsPath)
+		}
+	}
+
+	if restaurant.interrupted {
+		fmt.Println("\n=== Статистика прерванной смены ===")
+	} else {
+		fmt.Println("\n=== Финальная статистика ===")
+	}
+	restaurant.stats.Snapshot().printAll(true)
+	saveResults(restaurant.stats, exportDir)
+	if eventLog.Len() > 0 {
+		events, err := readEvents(&eventLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			os.Exit(1)
+		}
+		saveTimeline(events, timelineDir)
+	}
+
+	if restaurant.dashboard != nil && ctx.Err() == nil {
+		fmt.Println("Веб-панель показывает итоги смены, для выхода нажмите Ctrl+C")
+		<-ctx.Done()
+	}
+}
+
+// dashboardURLHost дополняет адрес вида ":8080" до "localhost:8080"
+func dashboardURLHost(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// === Веб-панель ===
+// Пока идёт симуляция, панель показывает очередь, столы, занятость персонала
+// и выручку. Снимок состояния собирается внутри симуляции при каждом событии,
+// а HTTP-обработчики читают только его копию.
+
+type Snapshot struct {
+	Time           time.Time      `json:"time"`
+	DoorsOpen      bool           `json:"doors_open"`
+	Finished       bool           `json:"finished"`
+	Queue          []QueuedParty  `json:"queue"`
+	Tables         []TableState   `json:"tables"`
+	Waiters        []StaffState   `json:"waiters"`
+	Chefs          []StaffState   `json:"chefs"`
+	Stations       []StationState `json:"stations"`
+	PartiesArrived int            `json:"parties_arrived"`
+	PartiesLost    int            `json:"parties_lost"`
+	OrdersServed   int            `json:"orders_served"`
+	Revenue        float64        `json:"revenue"`
+	LostRevenue    float64        `json:"lost_revenue"`
+}
+
+type QueuedParty struct {
+	Party   int     `json:"party"`
+	Size    int     `json:"size"`
+	WaitMin float64 `json:"wait_min"`
+}
+
+type TableState struct {
+	Table    int    `json:"table"`
+	Capacity int    `json:"capacity"`
+	VIP      bool   `json:"vip,omitempty"`
+	State    string `json:"state"`
+	Party    int    `json:"party,omitempty"`
+	Size     int    `json:"size,omitempty"`
+	Waiter   int    `json:"waiter,omitempty"` // закреплённый официант; 0 — общий зал
+}
+
+type StaffState struct {
+	ID    int    `json:"id"`
+	Busy  bool   `json:"busy"`
+	State string `json:"state"` // off, break, idle или busy
+	Task  string `json:"task,omitempty"`
+}
+
+func newStaffState(id int, task string) StaffState {
+	switch task {
+	case offDuty:
+		return StaffState{ID: id, State: "off"}
+	case onBreak:
+		return StaffState{ID: id, State: "break"}
+	case "":
+		return StaffState{ID: id, State: "idle"}
+	}
+	return StaffState{ID: id, Busy: true, State: "busy", Task: task}
+}
+
+type StationState struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+	Busy     int    `json:"busy"`
+	Queued   int    `json:"queued"`
+}
+
+func (r *Restaurant) setWaiterTask(waiterID int, task string) {
+	r.waiterTasks[waiterID-1] = task
+	if r.dashboard != nil {
+		r.dashboard.publish(r.snapshot(false))
+	}
+}
+
+func (r *Restaurant) setChefDish(chefID int, dish string) {
+	r.chefDishes[chefID-1] = dish
+	if r.dashboard != nil {
+		r.dashboard.publish(r.snapshot(false))
+	}
+}
+
+// snapshot вызывается только из процессов симуляции или после её окончания
+func (r *Restaurant) snapshot(finished bool) Snapshot {
+	now := r.clock.now
+	snap := Snapshot{Time: now, DoorsOpen: !r.floor.closed, Finished: finished}
+	for _, p := range r.floor.queue {
+		snap.Queue = append(snap.Queue, QueuedParty{Party: p.ID, Size: p.Size, WaitMin: minutes(now.Sub(p.Arrived))})
+	}
+	for _, t := range r.floor.tables {
+		state := TableState{Table: t.ID, Capacity: t.Capacity, VIP: t.VIP, State: "free"}
+		if t.held != nil {
+			state.State = "reserved"
+		}
+		if p := t.Party; p != nil {
+			state.State = p.state.String()
+			state.Party = p.ID
+			state.Size = p.Size
+			if p.Waiter != nil {
+				state.Waiter = p.Waiter.ID
+			}
+			if p.state == partyLeft {
+				state.State = "clearing"
+			}
+		}
+		snap.Tables = append(snap.Tables, state)
+	}
+	for i, task := range r.waiterTasks {
+		snap.Waiters = append(snap.Waiters, newStaffState(i+1, task))
+	}
+	for i, dish := range r.chefDishes {
+		snap.Chefs = append(snap.Chefs, newStaffState(i+1, dish))
+	}
+	for _, st := range r.kitchen.stations {
+		snap.Stations = append(snap.Stations, StationState{
+			Name:     st.Name,
+			Capacity: st.Capacity,
+			Busy:     st.busy,
+			Queued:   r.kitchen.queued(st.Name),
+		})
+	}
+
+	s := r.stats
+	s.mu.Lock()
+	for _, stats := range s.tableStats {
+		snap.Revenue += stats.TotalRevenue
+	}
+	snap.PartiesArrived = s.seating.PartiesArrived
+	snap.PartiesLost = s.seating.PartiesTurnedAway + s.seating.PartiesWalkedOut + s.seating.OrdersAbandoned
+	snap.OrdersServed = len(s.orders)
+	snap.LostRevenue = s.seating.LostRevenue
+	s.mu.Unlock()
+	return snap
+}
+
+type Dashboard struct {
+	mu      sync.Mutex
+	snap    Snapshot
+	changed chan struct{} // закрывается при каждом новом снимке
+	stats   *Stats
+}
+
+func newDashboard(stats *Stats) *Dashboard {
+	return &Dashboard{changed: make(chan struct{}), stats: stats}
+}
+
+func (d *Dashboard) publish(snap Snapshot) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snap = snap
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+func (d *Dashboard) current() (Snapshot, chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.snap, d.changed
+}
+
+// dashboardInterval — не чаще этого панель получает обновления по SSE
+const dashboardInterval = 250 * time.Millisecond
+
+func (d *Dashboard) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, dashboardPage)
+	})
+	mux.HandleFunc("/api/snapshot", func(w http.ResponseWriter, req *http.Request) {
+		snap, _ := d.current()
+		writeJSON(w, snap)
+	})
+	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, d.stats.Snapshot().report())
+	})
+	mux.HandleFunc("/events", d.serveEvents)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// serveEvents отправляет снимки как server-sent events
+func (d *Dashboard) serveEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "потоковая передача не поддерживается", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for {
+		snap, changed := d.current()
+		data, err := json.Marshal(snap)
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-req.Context().Done():
+			return
+		}
+		select {
+		case <-time.After(dashboardInterval):
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+const dashboardPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Ресторан</title>
+<style>
+body { font-family: sans-serif; margin: 20px; background: #fafafa; }
+h2 { margin: 18px 0 8px; font-size: 18px; }
+.cards, .tables { display: flex; flex-wrap: wrap; gap: 10px; }
+.card, .table { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 8px 12px; }
+.card b { display: block; font-size: 22px; }
+.table { width: 130px; }
+.free { border-color: #8c8; } .seated, .ordered { border-color: #e90; }
+.eating { border-color: #c33; } .paid, .clearing { border-color: #999; }
+.vip { font-weight: bold; color: #a60; }
+table.list { border-collapse: collapse; background: #fff; }
+table.list td, table.list th { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+.idle { color: #999; }
+</style>
+</head>
+<body>
+<h1>Ресторан: <span id="time">—</span> <small id="status"></small></h1>
+<div class="cards">
+  <div class="card">Выручка, руб.<b id="revenue">0</b></div>
+  <div class="card">Потери, руб.<b id="lost">0</b></div>
+  <div class="card">Подано заказов<b id="served">0</b></div>
+  <div class="card">Пришло компаний<b id="arrived">0</b></div>
+  <div class="card">Ушли ни с чем<b id="partiesLost">0</b></div>
+  <div class="card">В очереди<b id="queue">0</b></div>
+</div>
+<h2>Столы</h2>
+<div class="tables" id="tables"></div>
+<h2>Станции кухни</h2>
+<table class="list" id="stations"></table>
+<h2>Повара</h2>
+<table class="list" id="chefs"></table>
+<h2>Официанты</h2>
+<table class="list" id="waiters"></table>
+<script>
+const states = {free: "свободен", reserved: "под бронь", seated: "ждут официанта", ordered: "ждут заказ",
+  eating: "едят", paid: "рассчитались", clearing: "уборка"};
+const esc = s => String(s).replace(/[&<>]/g, c => ({"&": "&amp;", "<": "&lt;", ">": "&gt;"}[c]));
+const staffStates = {off: "не на смене", break: "перерыв", idle: "свободен"};
+function staff(list) {
+  return "<tr><th>№</th><th>Занят</th></tr>" + (list || []).map(s =>
+    "<tr><td>" + s.id + "</td><td class='" + (s.busy ? "" : "idle") + "'>" +
+    (s.busy ? esc(s.task) : staffStates[s.state]) + "</td></tr>").join("");
+}
+function render(s) {
+  document.getElementById("time").textContent = s.time.substr(11, 5);
+  document.getElementById("status").textContent =
+    s.finished ? "смена завершена" : (s.doors_open ? "открыто" : "последние посадки");
+  document.getElementById("revenue").textContent = s.revenue.toFixed(2);
+  document.getElementById("lost").textContent = s.lost_revenue.toFixed(2);
+  document.getElementById("served").textContent = s.orders_served;
+  document.getElementById("arrived").textContent = s.parties_arrived;
+  document.getElementById("partiesLost").textContent = s.parties_lost;
+  document.getElementById("queue").textContent = (s.queue || []).length;
+  document.getElementById("tables").innerHTML = (s.tables || []).map(t =>
+    "<div class='table " + t.state + "'><span class='" + (t.vip ? "vip" : "") + "'>Стол " + t.table +
+    (t.vip ? " VIP" : "") + "</span> (" + t.capacity + " мест)<br>" + states[t.state] +
+    (t.party ? "<br>гости #" + t.party + ", " + t.size + " чел." : "") +
+    (t.waiter ? "<br>официант " + t.waiter : "") + "</div>").join("");
+  document.getElementById("stations").innerHTML = "<tr><th>Станция</th><th>Занято</th><th>В очереди</th></tr>" +
+    (s.stations || []).map(st => "<tr><td>" + esc(st.name) + "</td><td>" + st.busy + " / " + st.capacity +
+    "</td><td>" + st.queued + "</td></tr>").join("");
+  document.getElementById("chefs").innerHTML = staff(s.chefs);
+  document.getElementById("waiters").innerHTML = staff(s.waiters);
+}
+const source = new EventSource("/events");
+source.onmessage = e => render(JSON.parse(e.data));
+</script>
+</body>
+</html>
+`
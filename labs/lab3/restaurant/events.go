@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// === Журнал событий ===
+// Всё, что происходит в симуляции, публикуется как события. Статистика
+// строится только из них, поэтому её можно восстановить из сохранённого
+// журнала (go run . replay events.jsonl).
+
+const (
+	evRunStarted      = "run_started"
+	evPartyArrived    = "party_arrived"
+	evPartyQueued     = "party_queued"
+	evPartyTurnedAway = "party_turned_away"
+	evPartyWalkedOut  = "party_walked_out"
+	evPartySeated     = "party_seated"
+	evOrderSkipped    = "order_skipped"
+	evOrderPlaced     = "order_placed"
+	evDishQueued      = "dish_queued"
+	evDishDiscarded   = "dish_discarded"
+	evCookingStarted  = "cooking_started"
+	evCookingFinished = "cooking_finished"
+	evOrderReady      = "order_ready"
+	evOrderDelivered  = "order_delivered"
+	evOrderReturned   = "order_returned"
+	evPartyAbandoned  = "party_abandoned"
+	evBillPaid        = "bill_paid"
+	evPartyLeft       = "party_left"
+	evTableCleared    = "table_cleared"
+	evDoorsClosed     = "doors_closed"
+	evKitchenClosed   = "kitchen_closed"
+	evShiftStarted    = "shift_started"
+	evShiftEnded      = "shift_ended"
+	evBreakStarted    = "break_started"
+	evBreakEnded      = "break_ended"
+	evDishUnavailable = "dish_unavailable"
+	evStockOut        = "stock_out"
+	evCourseFired     = "course_fired"
+	evCourseServed    = "course_served"
+	evCourseEaten     = "course_eaten"
+
+	evOffsiteOrdered    = "offsite_ordered"
+	evOffsiteRefused    = "offsite_refused"
+	evOffsiteReady      = "offsite_ready"
+	evCourierDeparted   = "courier_departed"
+	evOffsiteHandedOver = "offsite_handed_over"
+	evCourierReturned   = "courier_returned"
+
+	evReservationBooked   = "reservation_booked"
+	evReservationDeclined = "reservation_declined"
+	evReservationHeld     = "reservation_held"
+	evReservationReleased = "reservation_released"
+	evReservationConflict = "reservation_conflict"
+
+	evTableReassigned = "table_reassigned"
+
+	evIncidentStarted = "incident_started"
+	evIncidentEnded   = "incident_ended"
+
+	evRunInterrupted = "run_interrupted"
+	evRunFinished    = "run_finished"
+)
+
+type Event struct {
+	Time    time.Time     `json:"time"`
+	Type    string        `json:"type"`
+	Party   int           `json:"party,omitempty"`
+	Order   int           `json:"order,omitempty"`
+	Ticket  int           `json:"ticket,omitempty"`
+	Table   int           `json:"table,omitempty"`
+	Waiter  int           `json:"waiter,omitempty"`
+	Chef    int           `json:"chef,omitempty"`
+	Station string        `json:"station,omitempty"`
+	Dish    string        `json:"dish,omitempty"`
+	Dishes  []string      `json:"dishes,omitempty"`
+	Size    int           `json:"size,omitempty"`
+	Amount  float64       `json:"amount,omitempty"`
+	Queue   int           `json:"queue,omitempty"`
+	Busy    time.Duration `json:"busy,omitempty"` // сколько официант был занят этим делом
+	VIP     bool          `json:"vip,omitempty"`
+	Reason  string        `json:"reason,omitempty"`
+	Run     *RunInfo      `json:"run,omitempty"`
+	Check   *Check        `json:"check,omitempty"`
+
+	Ingredient string `json:"ingredient,omitempty"`
+	Course     string `json:"course,omitempty"`
+
+	Channel  string     `json:"channel,omitempty"` // takeaway или delivery
+	Zone     string     `json:"zone,omitempty"`
+	Courier  int        `json:"courier,omitempty"`
+	Promised *time.Time `json:"promised,omitempty"`
+
+	Booking int        `json:"booking,omitempty"`
+	Slot    *time.Time `json:"slot,omitempty"` // время брони
+
+	Incident int `json:"incident,omitempty"`
+}
+
+// RunInfo описывает прогон: часы работы, политики, столы и станции
+type RunInfo struct {
+	Open     time.Time     `json:"open"`
+	Close    time.Time     `json:"close"`
+	Policy   string        `json:"policy"`
+	Patience string        `json:"patience"`
+	Seed     int64         `json:"seed"`
+	Tables   []TableInfo   `json:"tables"`
+	Stations []Station     `json:"stations"`
+	Menu     []Dish        `json:"menu"`
+	Staff    []StaffMember `json:"staff"`
+
+	Ingredients []Ingredient   `json:"ingredients,omitempty"`
+	Couriers    int            `json:"couriers,omitempty"`
+	Zones       []string       `json:"zones,omitempty"`      // районы доставки в порядке сценария
+	Assignment  string         `json:"assignment,omitempty"` // пусто — общий зал (pool)
+	MaxTables   int            `json:"max_tables,omitempty"`
+	Incidents   []IncidentInfo `json:"incidents,omitempty"`
+}
+
+// IncidentInfo — происшествие, как его запланировали в смене
+type IncidentInfo struct {
+	ID       int        `json:"id"`
+	Kind     string     `json:"kind"`
+	Title    string     `json:"title"`
+	At       time.Time  `json:"at"`
+	Until    *time.Time `json:"until,omitempty"`
+	Station  string     `json:"station,omitempty"`
+	Capacity int        `json:"capacity,omitempty"`
+	Dishes   []string   `json:"dishes,omitempty"`
+}
+
+type TableInfo struct {
+	ID       int  `json:"id"`
+	Capacity int  `json:"capacity"`
+	VIP      bool `json:"vip,omitempty"`
+}
+
+// emit проставляет виртуальное время, пишет событие в журнал и учитывает
+// его в статистике
+func (r *Restaurant) emit(ev Event) {
+	ev.Time = r.clock.now
+	if r.events != nil {
+		if err := r.events.Encode(ev); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка: не удалось записать событие: %v\n", err)
+			r.events = nil
+		}
+	}
+	r.stats.apply(ev)
+	if r.dashboard != nil {
+		r.dashboard.publish(r.snapshot(ev.Type == evRunFinished))
+	}
+}
+
+func (r *Restaurant) runInfo() *RunInfo {
+	info := &RunInfo{
+		Open:     r.openTime,
+		Close:    r.closeTime,
+		Policy:   r.kitchen.policy.Name(),
+		Patience: r.patience.String(),
+		Seed:     r.cfg.Seed,
+		Menu:     r.menu,
+
+		Ingredients: r.cfg.Ingredients,
+		MaxTables:   r.cfg.MaxTables,
+	}
+	if _, pool := r.cfg.Assignment.(poolAssignment); !pool {
+		info.Assignment = r.cfg.Assignment.Name()
+	}
+	if r.cfg.Offsite.DeliveryRate > 0 {
+		info.Couriers = r.cfg.Offsite.Couriers
+		for _, z := range r.cfg.Offsite.Zones {
+			info.Zones = append(info.Zones, z.Name)
+		}
+	}
+	for _, m := range r.staff {
+		info.Staff = append(info.Staff, *m)
+	}
+	for _, inc := range r.incidents {
+		ii := IncidentInfo{ID: inc.ID, Kind: inc.Kind, Title: inc.title(), At: inc.start,
+			Station: inc.Station, Capacity: inc.Capacity, Dishes: inc.Dishes}
+		if !inc.end.IsZero() {
+			until := inc.end
+			ii.Until = &until
+		}
+		info.Incidents = append(info.Incidents, ii)
+	}
+	for _, t := range r.floor.tables {
+		info.Tables = append(info.Tables, TableInfo{ID: t.ID, Capacity: t.Capacity, VIP: t.VIP})
+	}
+	for _, st := range r.kitchen.stations {
+		info.Stations = append(info.Stations, Station{Name: st.Name, Capacity: st.Capacity})
+	}
+	return info
+}
+
+// replay восстанавливает статистику из журнала событий
+func replay(path string) (*Stats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при открытии журнала: %v", err)
+	}
+	defer f.Close()
+
+	events, err := readEvents(f)
+	if err != nil {
+		return nil, err
+	}
+	s := newStats()
+	for _, ev := range events {
+		s.apply(ev)
+	}
+	if len(events) == 0 || events[len(events)-1].Type != evRunFinished {
+		fmt.Fprintln(os.Stderr, "Внимание: журнал обрывается до конца прогона, статистика неполная")
+	}
+	return s, nil
+}
+
+// readEvents разбирает журнал JSONL; пустые строки пропускаются
+func readEvents(in io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return nil, fmt.Errorf("ошибка при разборе строки %d: %v", line, err)
+		}
+		if len(events) == 0 && ev.Type != evRunStarted {
+			return nil, fmt.Errorf("журнал должен начинаться с события %s", evRunStarted)
+		}
+		if ev.Type == evRunStarted && (ev.Run == nil || len(ev.Run.Menu) == 0) {
+			return nil, fmt.Errorf("строка %d: в событии %s нет меню прогона", line, evRunStarted)
+		}
+		if ev.Type == evBillPaid && ev.Check == nil {
+			return nil, fmt.Errorf("строка %d: в событии %s нет счёта", line, evBillPaid)
+		}
+		events = append(events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении журнала: %v", err)
+	}
+	return events, nil
+}
+
+// runReplay пересчитывает статистику по сохранённому журналу событий:
+// go run . replay events.jsonl [каталог для выгрузки]
+func runReplay(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Использование: go run . replay <журнал.jsonl> [каталог]")
+		os.Exit(2)
+	}
+	stats, err := replay(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Прогон %s — %s, политика кухни: %s, терпение: %s\n",
+		formatTime(stats.run.Open), formatTime(stats.run.Close), stats.run.Policy, stats.run.Patience)
+	fmt.Println("\n=== Статистика по журналу ===")
+	stats.Snapshot().printAll(true)
+	if len(args) > 1 {
+		saveResults(stats, args[1])
+	}
+}
@@ -0,0 +1,1260 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// === Выгрузка результатов ===
+
+// staffReports — показатели каждого сотрудника, сначала повара, потом
+// официанты
+func (s *StatsSnapshot) staffReports() []StaffReport {
+	keys := make([]staffKey, 0, len(s.staffStats))
+	for k := range s.staffStats {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Role != keys[j].Role {
+			return keys[i].Role < keys[j].Role
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	shiftOf := make(map[staffKey]string)
+	for _, m := range s.run.Staff {
+		shiftOf[staffKey{m.Role, m.ID}] = m.Shift
+	}
+	var reports []StaffReport
+	for _, k := range keys {
+		stats := s.staffStats[k]
+		delivery := sortDurations(stats.Delivery)
+		reports = append(reports, StaffReport{
+			Role:           k.Role,
+			ID:             k.ID,
+			Shift:          shiftOf[k],
+			Orders:         stats.Orders,
+			Dishes:         stats.Dishes,
+			Revenue:        stats.Revenue,
+			Breaks:         stats.Breaks,
+			BreakMin:       minutes(stats.BreakTime),
+			Taken:          stats.Taken,
+			Deliveries:     stats.Deliveries,
+			Tables:         len(stats.tables),
+			Parties:        stats.Parties,
+			Covered:        stats.Covered,
+			PeakTables:     stats.PeakTables,
+			AvgDeliveryMin: minutes(mean(delivery)),
+			P90DeliveryMin: minutes(percentile(delivery, 90)),
+			DutyMin:        minutes(stats.DutyTime),
+			BusyMin:        minutes(stats.BusyTime),
+			IdleMin:        minutes(stats.IdleTime()),
+			UtilizationPct: stats.Utilization(),
+		})
+	}
+	return reports
+}
+
+// balanceReport — насколько ровно закрепление столов загрузило официантов
+func (s *StatsSnapshot) balanceReport(staff []StaffReport) BalanceReport {
+	rep := BalanceReport{Assignment: s.run.Assignment, MaxTables: s.run.MaxTables}
+	if rep.Assignment == "" {
+		rep.Assignment = "pool"
+	}
+	var util, perHour []float64
+	for _, st := range staff {
+		if st.Role != "waiter" || st.DutyMin == 0 {
+			continue
+		}
+		if rep.Waiters == 0 || st.UtilizationPct < rep.MinUtilizationPct {
+			rep.MinUtilizationPct = st.UtilizationPct
+		}
+		if st.UtilizationPct > rep.MaxUtilizationPct {
+			rep.MaxUtilizationPct = st.UtilizationPct
+		}
+		if st.PeakTables > rep.MaxPeakTables {
+			rep.MaxPeakTables = st.PeakTables
+		}
+		rep.Waiters++
+		rep.HandedOver += st.Covered
+		util = append(util, st.UtilizationPct)
+		perHour = append(perHour, float64(st.Taken)/(st.DutyMin/60))
+	}
+	rep.UtilizationCVPct = spreadPct(util)
+	rep.OrdersPerHourCVPct = spreadPct(perHour)
+	return rep
+}
+
+func (s *StatsSnapshot) incidentReports() []IncidentReport {
+	var reports []IncidentReport
+	for _, inc := range s.run.Incidents {
+		rep := IncidentReport{ID: inc.ID, Kind: inc.Kind, Title: inc.Title}
+		span, ok := s.incidents[inc.ID]
+		if ok {
+			started := span.Started
+			rep.Started = &started
+			rep.Phases = append(rep.Phases, s.phaseReport("before", s.run.Open, started, false))
+			if span.Ended.IsZero() {
+				rep.Phases = append(rep.Phases, s.phaseReport("during", started, s.run.Close, true))
+			} else {
+				ended := span.Ended
+				rep.Ended = &ended
+				rep.Phases = append(rep.Phases,
+					s.phaseReport("during", started, ended, false),
+					s.phaseReport("after", ended, s.run.Close, true))
+			}
+		}
+		reports = append(reports, rep)
+	}
+	return reports
+}
+
+// phaseReport считает показатели отрезка [from, to); last — отрезок до конца
+// смены, в него попадает и то, что случилось после закрытия
+func (s *StatsSnapshot) phaseReport(phase string, from, to time.Time, last bool) PhaseReport {
+	in := func(t time.Time) bool {
+		return !t.Before(from) && (last || t.Before(to))
+	}
+	rep := PhaseReport{Phase: phase, From: from, To: to}
+	for _, t := range s.arrivals {
+		if in(t) {
+			rep.PartiesArrived++
+		}
+	}
+	for _, l := range s.losses {
+		if in(l.Arrived) {
+			rep.PartiesLost++
+			rep.LostRevenue += l.Amount
+		}
+	}
+	var total, kitchen []time.Duration
+	for _, o := range s.orders {
+		if !in(o.Delivered) {
+			continue
+		}
+		rep.OrdersServed++
+		rep.Revenue += o.Price
+		total = append(total, o.TotalTime())
+		kitchen = append(kitchen, o.KitchenTime())
+	}
+	rep.LostPct = percentOf(float64(rep.PartiesLost), float64(rep.PartiesArrived))
+	if hours := to.Sub(from).Hours(); hours > 0 {
+		rep.OrdersPerHour = float64(rep.OrdersServed) / hours
+	}
+	total, kitchen = sortDurations(total), sortDurations(kitchen)
+	rep.P50TotalMin = minutes(percentile(total, 50))
+	rep.P90TotalMin = minutes(percentile(total, 90))
+	rep.P90KitchenMin = minutes(percentile(kitchen, 90))
+	return rep
+}
+
+// spreadPct — стандартное отклонение в процентах от среднего
+func spreadPct(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	avg := sum / float64(len(xs))
+	var sq float64
+	for _, x := range xs {
+		sq += (x - avg) * (x - avg)
+	}
+	return percentOf(math.Sqrt(sq/float64(len(xs))), avg)
+}
+
+// courseReports — подача по курсам в порядке трапезы
+func (s *StatsSnapshot) courseReports() []CourseReport {
+	var reports []CourseReport
+	for _, course := range courses {
+		stats, ok := s.courseStats[course]
+		if !ok {
+			continue
+		}
+		gaps := sortDurations(stats.Gaps)
+		row := CourseReport{
+			Course:    course,
+			Served:    stats.Served,
+			AvgEatMin: minutes(mean(stats.Eat)),
+			P50GapMin: minutes(percentile(gaps, 50)),
+			P90GapMin: minutes(percentile(gaps, 90)),
+		}
+		if len(gaps) > 0 {
+			row.MaxGapMin = minutes(gaps[len(gaps)-1])
+		}
+		reports = append(reports, row)
+	}
+	return reports
+}
+
+// offsiteReports — каналы навынос и с доставкой, по которым были заказы
+func (s *StatsSnapshot) offsiteReports() []OffsiteReport {
+	var reports []OffsiteReport
+	for _, channel := range channels {
+		stats, ok := s.offsite[channel]
+		if !ok {
+			continue
+		}
+		lead := sortDurations(stats.Lead)
+		reports = append(reports, OffsiteReport{
+			Channel:    channel,
+			Orders:     stats.Orders,
+			Refused:    stats.Refused,
+			Handed:     stats.Handed,
+			Revenue:    stats.Revenue,
+			OnTimePct:  percentOf(float64(stats.OnTime), float64(stats.Handed)),
+			AvgLeadMin: minutes(mean(lead)),
+			P90LeadMin: minutes(percentile(lead, 90)),
+			AvgLateMin: minutes(mean(stats.Late)),
+		})
+	}
+	return reports
+}
+
+// zoneReports — районы доставки в порядке сценария
+func (s *StatsSnapshot) zoneReports() []ZoneReport {
+	var reports []ZoneReport
+	for _, name := range s.run.Zones {
+		var stats ZoneStats
+		if st, ok := s.zones[name]; ok {
+			stats = *st
+		}
+		travel := sortDurations(stats.Travel)
+		reports = append(reports, ZoneReport{
+			Zone:         name,
+			Deliveries:   stats.Deliveries,
+			OnTimePct:    percentOf(float64(stats.OnTime), float64(stats.Deliveries)),
+			AvgTravelMin: minutes(mean(travel)),
+			P90TravelMin: minutes(percentile(travel, 90)),
+		})
+	}
+	return reports
+}
+
+func (s *StatsSnapshot) courierReports() []CourierReport {
+	var reports []CourierReport
+	open := s.run.Close.Sub(s.run.Open)
+	for id := 1; id <= s.run.Couriers; id++ {
+		var stats CourierStats
+		if st, ok := s.couriers[id]; ok {
+			stats = *st
+		}
+		reports = append(reports, CourierReport{
+			ID:             id,
+			Deliveries:     stats.Deliveries,
+			BusyMin:        minutes(stats.BusyTime),
+			UtilizationPct: percentOf(stats.BusyTime.Seconds(), open.Seconds()),
+		})
+	}
+	return reports
+}
+
+func (s *StatsSnapshot) reservationReport() ReservationReport {
+	res := s.reservations
+	rep := ReservationReport{
+		Booked:         res.Booked,
+		Declined:       res.Declined,
+		Arrived:        res.Arrived,
+		NoShows:        res.NoShows,
+		LateArrivals:   res.LateArrivals,
+		ReleasedLate:   res.ReleasedLate,
+		Seated:         res.Seated,
+		WalkedOut:      res.WalkedOut,
+		Conflicts:      res.Conflicts,
+		FillRatePct:    percentOf(float64(res.Seated), float64(res.Booked)),
+		NoShowPct:      percentOf(float64(res.NoShows), float64(res.Booked)),
+		HeldIdleMin:    minutes(res.HeldIdle),
+		WalkInsArrived: s.seating.PartiesArrived - res.Arrived,
+		WalkInsSeated:  s.seating.PartiesSeated - res.Seated,
+	}
+	if res.LateArrivals > 0 {
+		rep.AvgLateMin = minutes(res.Late / time.Duration(res.LateArrivals))
+	}
+	if res.Seated > 0 {
+		rep.AvgSeatWaitMin = minutes(res.SeatWait / time.Duration(res.Seated))
+	}
+	if rep.WalkInsSeated > 0 {
+		rep.WalkInAvgWaitMin = minutes((s.seating.TotalWait - res.SeatWait) / time.Duration(rep.WalkInsSeated))
+	}
+	turnedAway := 0
+	for _, b := range s.bookings {
+		if b.Status == "turned_away" {
+			turnedAway++
+		}
+	}
+	lost := s.seating.PartiesTurnedAway - turnedAway + s.seating.PartiesWalkedOut - res.WalkedOut
+	rep.WalkInsLostPct = percentOf(float64(lost), float64(rep.WalkInsArrived))
+	rep.ReservedSharePct = percentOf(float64(res.Seated), float64(s.seating.PartiesSeated))
+	return rep
+}
+
+// bookingReports — книга брони по номерам
+func (s *StatsSnapshot) bookingReports() []BookingReport {
+	ids := make([]int, 0, len(s.bookings))
+	for id := range s.bookings {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	var reports []BookingReport
+	for _, id := range ids {
+		b := s.bookings[id]
+		row := BookingReport{
+			Booking:     id,
+			Slot:        b.Slot,
+			Size:        b.Size,
+			Table:       b.Table,
+			Status:      b.Status,
+			SeatedTable: b.SeatedTable,
+		}
+		if !b.Arrived.IsZero() {
+			arrived := b.Arrived
+			row.Arrived = &arrived
+			if late := b.Arrived.Sub(b.Slot); late > 0 {
+				row.LateMin = minutes(late)
+			}
+		}
+		if !b.Seated.IsZero() {
+			row.WaitMin = minutes(b.Seated.Sub(b.Arrived))
+		}
+		reports = append(reports, row)
+	}
+	return reports
+}
+
+func mean(xs []time.Duration) time.Duration {
+	if len(xs) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, x := range xs {
+		total += x
+	}
+	return total / time.Duration(len(xs))
+}
+
+// portionCost — себестоимость порции по ценам продуктов склада
+func (s *StatsSnapshot) portionCost(dish string) float64 {
+	var cost float64
+	for _, ing := range s.run.Ingredients {
+		cost += s.recipes[dish][ing.Name] * ing.Cost
+	}
+	return cost
+}
+
+// inventoryReports — остатки в порядке списка продуктов сценария
+func (s *StatsSnapshot) inventoryReports() []IngredientReport {
+	var reports []IngredientReport
+	for _, ing := range s.run.Ingredients {
+		stats := s.ingredients[ing.Name]
+		row := IngredientReport{
+			Ingredient: ing.Name,
+			Unit:       ing.Unit,
+			Cost:       ing.Cost,
+			Opening:    ing.Stock,
+			Used:       roundMoney(stats.Used),
+			Wasted:     roundMoney(stats.Wasted),
+			Closing:    math.Max(0, roundMoney(ing.Stock-stats.Used)),
+			Shortage:   roundMoney(stats.Shortage),
+			UsedCost:   roundMoney(stats.Used * ing.Cost),
+		}
+		row.Purchase = math.Max(0, roundMoney(row.Used+row.Shortage-row.Closing))
+		if !stats.OutAt.IsZero() {
+			t := stats.OutAt
+			row.OutAt = &t
+		}
+		reports = append(reports, row)
+	}
+	return reports
+}
+
+// billingReport сводит итоги кассы; акции и способы оплаты идут по алфавиту
+func (s *StatsSnapshot) billingReport() BillingReport {
+	b := s.billing
+	rep := BillingReport{
+		Checks:      b.Checks,
+		SplitChecks: b.Split,
+		GrossSales:  roundMoney(b.Gross),
+		Discounts:   roundMoney(b.Discounts),
+		Service:     roundMoney(b.Service),
+		Total:       roundMoney(b.Total),
+		VAT:         roundMoney(b.VAT),
+		NetRevenue:  roundMoney(b.Total - b.VAT),
+		Tips:        roundMoney(b.Tips),
+		AvgTipPct:   percentOf(b.Tips, b.Total),
+	}
+	if b.Checks > 0 {
+		rep.AvgCheck = roundMoney(b.Total / float64(b.Checks))
+	}
+	promos := make([]string, 0, len(b.Promos))
+	for name := range b.Promos {
+		promos = append(promos, name)
+	}
+	sort.Strings(promos)
+	for _, name := range promos {
+		rep.Promotions = append(rep.Promotions, PromoReport{Promo: name, Discount: roundMoney(b.Promos[name])})
+	}
+	methods := make([]string, 0, len(b.Payments))
+	for m := range b.Payments {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	for _, m := range methods {
+		ps := b.Payments[m]
+		rep.Payments = append(rep.Payments, PaymentReport{
+			Method: m, Count: ps.Count, Amount: roundMoney(ps.Amount), Tips: roundMoney(ps.Tips),
+		})
+	}
+	return rep
+}
+
+// shiftReports сводит показатели сотрудников по сменам в порядке графика
+func (s *StatsSnapshot) shiftReports() []ShiftReport {
+	var shifts []ShiftReport
+	index := make(map[[2]string]int)
+	for _, m := range s.run.Staff {
+		key := [2]string{m.Shift, m.Role}
+		i, ok := index[key]
+		if !ok {
+			i = len(shifts)
+			index[key] = i
+			shifts = append(shifts, ShiftReport{Shift: m.Shift, Role: m.Role, Start: m.Start, End: m.End})
+		}
+		sh := &shifts[i]
+		sh.Staff++
+		if stats, ok := s.staffStats[staffKey{m.Role, m.ID}]; ok {
+			sh.Orders += stats.Orders
+			sh.Dishes += stats.Dishes
+			sh.Revenue += stats.Revenue
+			sh.Breaks += stats.Breaks
+			sh.BreakMin += minutes(stats.BreakTime)
+		}
+	}
+	for i := range shifts {
+		sh := &shifts[i]
+		// выручка на человеко-час в пределах часов работы
+		from, to := sh.Start, sh.End
+		if from.Before(s.run.Open) {
+			from = s.run.Open
+		}
+		if to.After(s.run.Close) {
+			to = s.run.Close
+		}
+		if hours := to.Sub(from).Hours() * float64(sh.Staff); hours > 0 {
+			sh.RevenuePerStaffHour = sh.Revenue / hours
+		}
+	}
+	return shifts
+}
+
+func (s *StatsSnapshot) coverageGaps() []GapReport {
+	var gaps []GapReport
+	for _, role := range []string{"chef", "waiter"} {
+		c, ok := s.coverage[role]
+		if !ok {
+			continue
+		}
+		for _, g := range c.gaps {
+			gaps = append(gaps, GapReport{Role: role, From: g.From, To: g.To, Minutes: minutes(g.To.Sub(g.From))})
+		}
+	}
+	return gaps
+}
+
+const exportSchemaVersion = 2
+
+type RunReport struct {
+	SchemaVersion int                `json:"schema_version"`
+	Open          time.Time          `json:"open"`
+	Close         time.Time          `json:"close"`
+	Policy        string             `json:"policy"`
+	Patience      string             `json:"patience"`
+	InterruptedAt *time.Time         `json:"interrupted_at,omitempty"`
+	Summary       SummaryReport      `json:"summary"`
+	Tables        []TableReport      `json:"tables"`
+	Dishes        []DishReport       `json:"dishes"`
+	Staff         []StaffReport      `json:"staff"`
+	Shifts        []ShiftReport      `json:"shifts"`
+	CoverageGaps  []GapReport        `json:"coverage_gaps"`
+	Hours         []HourReport       `json:"hours"`
+	Orders        []OrderReport      `json:"orders"`
+	Billing       BillingReport      `json:"billing"`
+	Checks        []CheckReport      `json:"checks"`
+	Inventory     []IngredientReport `json:"inventory"`
+	Courses       []CourseReport     `json:"courses"`
+	Offsite       []OffsiteReport    `json:"offsite"`
+	Zones         []ZoneReport       `json:"zones"`
+	Couriers      []CourierReport    `json:"couriers"`
+	Reservations  ReservationReport  `json:"reservations"`
+	Bookings      []BookingReport    `json:"bookings"`
+	Balance       BalanceReport      `json:"balance"`
+	Incidents     []IncidentReport   `json:"incidents"`
+}
+
+// IncidentReport — показатели зала до, во время и после происшествия
+type IncidentReport struct {
+	ID      int           `json:"id"`
+	Kind    string        `json:"kind"`
+	Title   string        `json:"title"`
+	Started *time.Time    `json:"started"` // nil — смену прервали раньше
+	Ended   *time.Time    `json:"ended"`   // nil — длилось до конца дня
+	Phases  []PhaseReport `json:"phases"`
+}
+
+// PhaseReport — отрезок смены относительно происшествия. Гости считаются по
+// времени прихода, заказы — по времени подачи; последний отрезок включает
+// всё, что зал дорабатывал после закрытия.
+type PhaseReport struct {
+	Phase          string    `json:"phase"` // before, during или after
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	PartiesArrived int       `json:"parties_arrived"`
+	PartiesLost    int       `json:"parties_lost"`
+	LostPct        float64   `json:"lost_pct"`
+	LostRevenue    float64   `json:"lost_revenue"`
+	OrdersServed   int       `json:"orders_served"`
+	OrdersPerHour  float64   `json:"orders_per_hour"`
+	Revenue        float64   `json:"revenue"`
+	P50TotalMin    float64   `json:"p50_total_min"`
+	P90TotalMin    float64   `json:"p90_total_min"`
+	P90KitchenMin  float64   `json:"p90_kitchen_min"`
+}
+
+// BalanceReport — как работа зала разошлась между официантами, вышедшими на
+// смену; разброс — стандартное отклонение в процентах от среднего
+type BalanceReport struct {
+	Assignment         string  `json:"assignment"`
+	MaxTables          int     `json:"max_tables"` // 0 — без ограничения
+	Waiters            int     `json:"waiters"`
+	MinUtilizationPct  float64 `json:"min_utilization_pct"`
+	MaxUtilizationPct  float64 `json:"max_utilization_pct"`
+	UtilizationCVPct   float64 `json:"utilization_cv_pct"`
+	OrdersPerHourCVPct float64 `json:"orders_per_hour_cv_pct"` // принятые заказы на час смены
+	MaxPeakTables      int     `json:"max_peak_tables"`
+	HandedOver         int     `json:"handed_over"` // столы, переданные коллегам при уходе
+}
+
+// ReservationReport — брони и гости с улицы. Заполнение — доля принятых
+// броней, гостей по которым посадили
+type ReservationReport struct {
+	Booked           int     `json:"booked"`
+	Declined         int     `json:"declined"`
+	Arrived          int     `json:"arrived"`
+	NoShows          int     `json:"no_shows"`
+	LateArrivals     int     `json:"late_arrivals"`
+	ReleasedLate     int     `json:"released_late"`
+	Seated           int     `json:"seated"`
+	WalkedOut        int     `json:"walked_out"`
+	Conflicts        int     `json:"conflicts"`
+	FillRatePct      float64 `json:"fill_rate_pct"`
+	NoShowPct        float64 `json:"no_show_pct"`
+	AvgLateMin       float64 `json:"avg_late_min"` // среди опоздавших
+	AvgSeatWaitMin   float64 `json:"avg_seat_wait_min"`
+	HeldIdleMin      float64 `json:"held_idle_min"`      // столо-минуты, когда стол держали пустым
+	ReservedSharePct float64 `json:"reserved_share_pct"` // доля брони среди посаженных компаний
+	WalkInsArrived   int     `json:"walk_ins_arrived"`
+	WalkInsSeated    int     `json:"walk_ins_seated"`
+	WalkInAvgWaitMin float64 `json:"walk_in_avg_wait_min"`
+	WalkInsLostPct   float64 `json:"walk_ins_lost_pct"` // не пустили или не дождались стола
+}
+
+// BookingReport — строка книги брони
+type BookingReport struct {
+	Booking     int        `json:"booking"`
+	Slot        time.Time  `json:"slot"`
+	Size        int        `json:"size"`
+	Table       int        `json:"table,omitempty"`
+	Status      string     `json:"status"` // seated, no_show, released, walked_out, turned_away, cancelled, declined
+	Arrived     *time.Time `json:"arrived,omitempty"`
+	LateMin     float64    `json:"late_min"`
+	SeatedTable int        `json:"seated_table,omitempty"`
+	WaitMin     float64    `json:"wait_min"`
+}
+
+// OffsiteReport — канал навынос или с доставкой. Вовремя — гость получил
+// заказ не позже обещанного срока
+type OffsiteReport struct {
+	Channel    string  `json:"channel"`
+	Orders     int     `json:"orders"`
+	Refused    int     `json:"refused"`
+	Handed     int     `json:"handed_over"`
+	Revenue    float64 `json:"revenue"`
+	OnTimePct  float64 `json:"on_time_pct"`
+	AvgLeadMin float64 `json:"avg_lead_min"`
+	P90LeadMin float64 `json:"p90_lead_min"`
+	AvgLateMin float64 `json:"avg_late_min"` // среди опоздавших
+}
+
+type ZoneReport struct {
+	Zone         string  `json:"zone"`
+	Deliveries   int     `json:"deliveries"`
+	OnTimePct    float64 `json:"on_time_pct"`
+	AvgTravelMin float64 `json:"avg_travel_min"`
+	P90TravelMin float64 `json:"p90_travel_min"`
+}
+
+type CourierReport struct {
+	ID             int     `json:"id"`
+	Deliveries     int     `json:"deliveries"`
+	BusyMin        float64 `json:"busy_min"`
+	UtilizationPct float64 `json:"utilization_pct"` // в дороге в часы работы
+}
+
+// CourseReport — подача курса; пауза — от того, как доели предыдущий курс,
+// до подачи этого
+type CourseReport struct {
+	Course    string  `json:"course"`
+	Served    int     `json:"served"`
+	AvgEatMin float64 `json:"avg_eat_min"`
+	P50GapMin float64 `json:"p50_gap_min"`
+	P90GapMin float64 `json:"p90_gap_min"`
+	MaxGapMin float64 `json:"max_gap_min"`
+}
+
+// BillingReport — итоги кассы. Чистая выручка — оплаченное без НДС и чаевых
+type BillingReport struct {
+	Checks      int             `json:"checks"`
+	SplitChecks int             `json:"split_checks"`
+	GrossSales  float64         `json:"gross_sales"`
+	Discounts   float64         `json:"discounts"`
+	Promotions  []PromoReport   `json:"promotions"`
+	Service     float64         `json:"service_charge"`
+	Total       float64         `json:"total"`
+	VAT         float64         `json:"vat"`
+	NetRevenue  float64         `json:"net_revenue"`
+	Tips        float64         `json:"tips"`
+	AvgCheck    float64         `json:"avg_check"`
+	AvgTipPct   float64         `json:"avg_tip_pct"`
+	Payments    []PaymentReport `json:"payments"`
+}
+
+type PromoReport struct {
+	Promo    string  `json:"promo"`
+	Discount float64 `json:"discount"`
+}
+
+type PaymentReport struct {
+	Method string  `json:"method"`
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+	Tips   float64 `json:"tips"`
+}
+
+type CheckReport struct {
+	Party     int         `json:"party"`
+	Table     int         `json:"table"`
+	Waiter    int         `json:"waiter"`
+	Guests    int         `json:"guests"`
+	Paid      time.Time   `json:"paid"`
+	Lines     []CheckLine `json:"lines"`
+	Gross     float64     `json:"gross"`
+	Discounts float64     `json:"discounts"`
+	Service   float64     `json:"service_charge"`
+	VAT       float64     `json:"vat"`
+	Total     float64     `json:"total"`
+	Tip       float64     `json:"tip"`
+	Payments  []Payment   `json:"payments"`
+}
+
+type SummaryReport struct {
+	PartiesArrived    int     `json:"parties_arrived"`
+	PartiesSeated     int     `json:"parties_seated"`
+	PartiesTurnedAway int     `json:"parties_turned_away"`
+	PartiesWalkedOut  int     `json:"parties_walked_out"`
+	OrdersAbandoned   int     `json:"orders_abandoned"`
+	OrdersServed      int     `json:"orders_served"`
+	Revenue           float64 `json:"revenue"`
+	LostRevenue       float64 `json:"lost_revenue"`
+	AvgWaitToSeatMin  float64 `json:"avg_wait_to_seat_min"`
+	AvgServeMin       float64 `json:"avg_serve_min"`
+	P50TotalMin       float64 `json:"p50_total_min"`
+	P90TotalMin       float64 `json:"p90_total_min"`
+	P99TotalMin       float64 `json:"p99_total_min"`
+	MaxTotalMin       float64 `json:"max_total_min"`
+	LeftSoldOut       int     `json:"left_sold_out"`
+	AvgDwellMin       float64 `json:"avg_dwell_min"` // от посадки до ухода у оплативших компаний
+	FoodCost          float64 `json:"food_cost"`
+	WasteCost         float64 `json:"waste_cost"`
+	FoodCostPct       float64 `json:"food_cost_pct"`   // расход продуктов к продажам по меню
+	OffsiteRevenue    float64 `json:"offsite_revenue"` // навынос и доставка; в revenue не входит
+}
+
+type TableReport struct {
+	Table            int     `json:"table"`
+	Capacity         int     `json:"capacity"`
+	VIP              bool    `json:"vip"`
+	Orders           int     `json:"orders"`
+	Revenue          float64 `json:"revenue"`
+	AvgServeMin      float64 `json:"avg_serve_min"`
+	Turns            int     `json:"turns"`
+	OccupancyPct     float64 `json:"occupancy_pct"`
+	AvgWaitToSeatMin float64 `json:"avg_wait_to_seat_min"`
+	Abandoned        int     `json:"abandoned"`
+	LostRevenue      float64 `json:"lost_revenue"`
+}
+
+type DishReport struct {
+	Dish          string     `json:"dish"`
+	Station       string     `json:"station"`
+	Portions      int        `json:"portions"`
+	Revenue       float64    `json:"revenue"`
+	P50KitchenMin float64    `json:"p50_kitchen_min"`
+	P90KitchenMin float64    `json:"p90_kitchen_min"`
+	FoodCost      float64    `json:"food_cost"` // себестоимость порции по остаткам склада
+	FoodCostPct   float64    `json:"food_cost_pct"`
+	Refused       int        `json:"refused"`
+	SoldOutAt     *time.Time `json:"sold_out_at,omitempty"`
+}
+
+// IngredientReport — остатки продукта; к закупке — сколько нужно, чтобы
+// покрыть вчерашний расход и то, чего не хватило
+type IngredientReport struct {
+	Ingredient string     `json:"ingredient"`
+	Unit       string     `json:"unit"`
+	Cost       float64    `json:"cost"`
+	Opening    float64    `json:"opening"`
+	Used       float64    `json:"used"`
+	Wasted     float64    `json:"wasted"`
+	Closing    float64    `json:"closing"`
+	Shortage   float64    `json:"shortage"`
+	Purchase   float64    `json:"purchase"`
+	UsedCost   float64    `json:"used_cost"`
+	OutAt      *time.Time `json:"out_at,omitempty"`
+}
+
+type StaffReport struct {
+	Role           string  `json:"role"`
+	ID             int     `json:"id"`
+	Shift          string  `json:"shift"`
+	Orders         int     `json:"orders"`
+	Dishes         int     `json:"dishes"`
+	Revenue        float64 `json:"revenue"`
+	Breaks         int     `json:"breaks"`
+	BreakMin       float64 `json:"break_min"`
+	Taken          int     `json:"taken"`
+	Deliveries     int     `json:"deliveries"`
+	Tables         int     `json:"tables"`
+	Parties        int     `json:"parties"`     // компании, закреплённые при посадке
+	Covered        int     `json:"covered"`     // столы, принятые от коллег
+	PeakTables     int     `json:"peak_tables"` // больше всего закреплённых столов сразу
+	AvgDeliveryMin float64 `json:"avg_delivery_min"`
+	P90DeliveryMin float64 `json:"p90_delivery_min"`
+	DutyMin        float64 `json:"duty_min"`
+	BusyMin        float64 `json:"busy_min"`
+	IdleMin        float64 `json:"idle_min"`
+	UtilizationPct float64 `json:"utilization_pct"`
+}
+
+type ShiftReport struct {
+	Shift               string    `json:"shift"`
+	Role                string    `json:"role"`
+	Start               time.Time `json:"start"`
+	End                 time.Time `json:"end"`
+	Staff               int       `json:"staff"`
+	Orders              int       `json:"orders"`
+	Dishes              int       `json:"dishes"`
+	Revenue             float64   `json:"revenue"`
+	RevenuePerStaffHour float64   `json:"revenue_per_staff_hour"`
+	Breaks              int       `json:"breaks"`
+	BreakMin            float64   `json:"break_min"`
+}
+
+// GapReport — промежуток рабочего времени, когда на месте не было никого из роли
+type GapReport struct {
+	Role    string    `json:"role"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Minutes float64   `json:"minutes"`
+}
+
+type HourReport struct {
+	Hour           int     `json:"hour"`
+	PartiesArrived int     `json:"parties_arrived"`
+	WalkedOut      int     `json:"walked_out"`
+	Abandoned      int     `json:"abandoned"`
+	AbandonRatePct float64 `json:"abandon_rate_pct"`
+	Revenue        float64 `json:"revenue"`
+	LostRevenue    float64 `json:"lost_revenue"`
+}
+
+type OrderReport struct {
+	OrderID        int       `json:"order_id"`
+	Table          int       `json:"table"`
+	Waiter         int       `json:"waiter"`
+	Dishes         []string  `json:"dishes"`
+	Price          float64   `json:"price"`
+	Seated         time.Time `json:"seated"`
+	Ordered        time.Time `json:"ordered"`
+	Ready          time.Time `json:"ready"`
+	Delivered      time.Time `json:"delivered"`
+	WaitToOrderMin float64   `json:"wait_to_order_min"`
+	KitchenMin     float64   `json:"kitchen_min"`
+	DeliveryMin    float64   `json:"delivery_min"`
+	TotalMin       float64   `json:"total_min"`
+}
+
+func minutes(d time.Duration) float64 {
+	return math.Round(d.Minutes()*100) / 100
+}
+
+func percentOf(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(10000*part/whole) / 100
+}
+
+// report собирает все результаты прогона в одну структуру с постоянным
+// порядком строк: столы и часы по возрастанию, блюда по меню, персонал по ролям
+func (s *StatsSnapshot) report() RunReport {
+	openDuration := s.run.Close.Sub(s.run.Open)
+	rep := RunReport{
+		SchemaVersion: exportSchemaVersion,
+		Open:          s.run.Open,
+		Close:         s.run.Close,
+		Policy:        s.run.Policy,
+		Patience:      s.run.Patience,
+	}
+	if !s.interrupted.IsZero() {
+		at := s.interrupted
+		rep.InterruptedAt = &at
+	}
+
+	seat := s.seating
+	var revenue float64
+	var totals []time.Duration
+	for _, o := range s.orders {
+		revenue += o.Price
+		totals = append(totals, o.TotalTime())
+	}
+	sorted := sortDurations(totals)
+	rep.Summary = SummaryReport{
+		PartiesArrived:    seat.PartiesArrived,
+		PartiesSeated:     seat.PartiesSeated,
+		PartiesTurnedAway: seat.PartiesTurnedAway,
+		PartiesWalkedOut:  seat.PartiesWalkedOut,
+		OrdersAbandoned:   seat.OrdersAbandoned,
+		OrdersServed:      len(s.orders),
+		Revenue:           revenue,
+		LostRevenue:       seat.LostRevenue,
+		P50TotalMin:       minutes(percentile(sorted, 50)),
+		P90TotalMin:       minutes(percentile(sorted, 90)),
+		P99TotalMin:       minutes(percentile(sorted, 99)),
+		LeftSoldOut:       seat.LeftSoldOut,
+	}
+	if seat.PartiesSeated > 0 {
+		rep.Summary.AvgWaitToSeatMin = minutes(seat.TotalWait / time.Duration(seat.PartiesSeated))
+	}
+	if s.serve.Count > 0 {
+		rep.Summary.AvgServeMin = minutes(s.serve.Total / time.Duration(s.serve.Count))
+	}
+	if len(sorted) > 0 {
+		rep.Summary.MaxTotalMin = minutes(sorted[len(sorted)-1])
+	}
+
+	for _, t := range s.run.Tables {
+		var stats TableStats
+		if st, ok := s.tableStats[t.ID]; ok {
+			stats = *st
+		}
+		row := TableReport{
+			Table:        t.ID,
+			Capacity:     t.Capacity,
+			VIP:          t.VIP,
+			Orders:       stats.OrdersCount,
+			Revenue:      stats.TotalRevenue,
+			Turns:        stats.Turns,
+			OccupancyPct: percentOf(stats.OccupiedTime.Seconds(), openDuration.Seconds()),
+			Abandoned:    stats.Abandoned,
+			LostRevenue:  stats.LostRevenue,
+		}
+		if stats.OrdersCount > 0 {
+			row.AvgServeMin = minutes(stats.TotalTime / time.Duration(stats.OrdersCount))
+		}
+		if stats.Turns > 0 {
+			row.AvgWaitToSeatMin = minutes(stats.WaitToSeat / time.Duration(stats.Turns))
+		}
+		rep.Tables = append(rep.Tables, row)
+	}
+
+	for _, d := range s.run.Menu {
+		var data DishStats
+		if st, ok := s.dishStats[d.Name]; ok {
+			data = *st
+		}
+		latency := sortDurations(s.dishLatency[d.Name])
+		row := DishReport{
+			Dish:          d.Name,
+			Station:       d.Station,
+			Portions:      data.Portions,
+			Revenue:       data.Revenue,
+			P50KitchenMin: minutes(percentile(latency, 50)),
+			P90KitchenMin: minutes(percentile(latency, 90)),
+			FoodCost:      roundMoney(s.portionCost(d.Name)),
+			Refused:       s.refused[d.Name],
+		}
+		row.FoodCostPct = percentOf(row.FoodCost, d.BasePrice)
+		if t, ok := s.soldOutAt[d.Name]; ok {
+			row.SoldOutAt = &t
+		}
+		rep.Dishes = append(rep.Dishes, row)
+	}
+	rep.Inventory = s.inventoryReports()
+	rep.Courses = s.courseReports()
+	rep.Summary.AvgDwellMin = minutes(mean(s.dwell))
+	for _, ing := range rep.Inventory {
+		rep.Summary.FoodCost += ing.UsedCost
+		rep.Summary.WasteCost += roundMoney(ing.Wasted * ing.Cost)
+	}
+	rep.Summary.FoodCost = roundMoney(rep.Summary.FoodCost)
+	rep.Summary.WasteCost = roundMoney(rep.Summary.WasteCost)
+	rep.Reservations = s.reservationReport()
+	rep.Bookings = s.bookingReports()
+	rep.Offsite = s.offsiteReports()
+	rep.Zones = s.zoneReports()
+	rep.Couriers = s.courierReports()
+	for _, o := range rep.Offsite {
+		rep.Summary.OffsiteRevenue += o.Revenue
+	}
+	rep.Summary.FoodCostPct = percentOf(rep.Summary.FoodCost, s.billing.Gross+rep.Summary.OffsiteRevenue)
+
+	rep.Staff = s.staffReports()
+	rep.Balance = s.balanceReport(rep.Staff)
+	rep.Incidents = s.incidentReports()
+	rep.Billing = s.billingReport()
+	for _, c := range s.checks {
+		rep.Checks = append(rep.Checks, CheckReport{
+			Party:     c.Party,
+			Table:     c.Table,
+			Waiter:    c.Waiter,
+			Guests:    c.Guests,
+			Paid:      c.Paid,
+			Lines:     c.Check.Lines,
+			Gross:     c.Check.Gross,
+			Discounts: c.Check.Discounts,
+			Service:   c.Check.Service,
+			VAT:       c.Check.VAT,
+			Total:     c.Check.Total,
+			Tip:       c.Check.Tip,
+			Payments:  c.Check.Payments,
+		})
+	}
+	rep.Shifts = s.shiftReports()
+	rep.CoverageGaps = s.coverageGaps()
+
+	hours := make([]int, 0, len(s.hourStats))
+	for h := range s.hourStats {
+		hours = append(hours, h)
+	}
+	sort.Ints(hours)
+	for _, h := range hours {
+		stats := s.hourStats[h]
+		rep.Hours = append(rep.Hours, HourReport{
+			Hour:           h,
+			PartiesArrived: stats.PartiesArrived,
+			WalkedOut:      stats.WalkedOut,
+			Abandoned:      stats.Abandoned,
+			AbandonRatePct: percentOf(float64(stats.WalkedOut+stats.Abandoned), float64(stats.PartiesArrived)),
+			Revenue:        stats.Revenue,
+			LostRevenue:    stats.LostRevenue,
+		})
+	}
+
+	for _, o := range s.orders {
+		rep.Orders = append(rep.Orders, OrderReport{
+			OrderID:        o.OrderID,
+			Table:          o.TableID,
+			Waiter:         o.WaiterID,
+			Dishes:         o.Dishes,
+			Price:          o.Price,
+			Seated:         o.Seated,
+			Ordered:        o.Ordered,
+			Ready:          o.Ready,
+			Delivered:      o.Delivered,
+			WaitToOrderMin: minutes(o.WaitToOrder()),
+			KitchenMin:     minutes(o.KitchenTime()),
+			DeliveryMin:    minutes(o.DeliveryTime()),
+			TotalMin:       minutes(o.TotalTime()),
+		})
+	}
+	return rep
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatOptionalTime — пустая ячейка, если события не было
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании %s: %v", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("ошибка при записи %s: %v", path, err)
+	}
+	return nil
+}
+
+// exportResults сохраняет run.json и CSV-файлы по столам, блюдам, персоналу,
+// часам и заказам в каталог dir
+func exportResults(rep RunReport, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("ошибка при создании каталога: %v", err)
+	}
+
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации JSON: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.json"), data, 0o644); err != nil {
+		return fmt.Errorf("ошибка при записи run.json: %v", err)
+	}
+
+	var rows [][]string
+	for _, t := range rep.Tables {
+		rows = append(rows, []string{
+			strconv.Itoa(t.Table), strconv.Itoa(t.Capacity), strconv.FormatBool(t.VIP), strconv.Itoa(t.Orders),
+			formatFloat(t.Revenue), formatFloat(t.AvgServeMin), strconv.Itoa(t.Turns), formatFloat(t.OccupancyPct),
+			formatFloat(t.AvgWaitToSeatMin), strconv.Itoa(t.Abandoned), formatFloat(t.LostRevenue),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "tables.csv"), []string{
+		"table", "capacity", "vip", "orders", "revenue", "avg_serve_min", "turns", "occupancy_pct",
+		"avg_wait_to_seat_min", "abandoned", "lost_revenue",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, d := range rep.Dishes {
+		rows = append(rows, []string{
+			d.Dish, d.Station, strconv.Itoa(d.Portions), formatFloat(d.Revenue),
+			formatFloat(d.P50KitchenMin), formatFloat(d.P90KitchenMin), formatFloat(d.FoodCost),
+			formatFloat(d.FoodCostPct), strconv.Itoa(d.Refused), formatOptionalTime(d.SoldOutAt),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "dishes.csv"), []string{
+		"dish", "station", "portions", "revenue", "p50_kitchen_min", "p90_kitchen_min",
+		"food_cost", "food_cost_pct", "refused", "sold_out_at",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, s := range rep.Staff {
+		rows = append(rows, []string{
+			s.Role, strconv.Itoa(s.ID), strconv.Itoa(s.Orders), strconv.Itoa(s.Dishes), formatFloat(s.Revenue),
+			s.Shift, strconv.Itoa(s.Breaks), formatFloat(s.BreakMin), strconv.Itoa(s.Taken),
+			strconv.Itoa(s.Deliveries), strconv.Itoa(s.Tables), formatFloat(s.AvgDeliveryMin),
+			formatFloat(s.P90DeliveryMin), formatFloat(s.DutyMin), formatFloat(s.BusyMin), formatFloat(s.IdleMin),
+			formatFloat(s.UtilizationPct), strconv.Itoa(s.Parties), strconv.Itoa(s.Covered), strconv.Itoa(s.PeakTables),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "staff.csv"), []string{
+		"role", "id", "orders", "dishes", "revenue", "shift", "breaks", "break_min", "taken", "deliveries",
+		"tables", "avg_delivery_min", "p90_delivery_min", "duty_min", "busy_min", "idle_min", "utilization_pct",
+		"parties", "covered", "peak_tables",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, sh := range rep.Shifts {
+		rows = append(rows, []string{
+			sh.Shift, sh.Role, sh.Start.Format(time.RFC3339), sh.End.Format(time.RFC3339), strconv.Itoa(sh.Staff),
+			strconv.Itoa(sh.Orders), strconv.Itoa(sh.Dishes), formatFloat(sh.Revenue),
+			formatFloat(sh.RevenuePerStaffHour), strconv.Itoa(sh.Breaks), formatFloat(sh.BreakMin),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "shifts.csv"), []string{
+		"shift", "role", "start", "end", "staff", "orders", "dishes", "revenue", "revenue_per_staff_hour",
+		"breaks", "break_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, h := range rep.Hours {
+		rows = append(rows, []string{
+			strconv.Itoa(h.Hour), strconv.Itoa(h.PartiesArrived), strconv.Itoa(h.WalkedOut), strconv.Itoa(h.Abandoned),
+			formatFloat(h.AbandonRatePct), formatFloat(h.Revenue), formatFloat(h.LostRevenue),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "hours.csv"), []string{
+		"hour", "parties_arrived", "walked_out", "abandoned", "abandon_rate_pct", "revenue", "lost_revenue",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, o := range rep.Orders {
+		rows = append(rows, []string{
+			strconv.Itoa(o.OrderID), strconv.Itoa(o.Table), strconv.Itoa(o.Waiter), strings.Join(o.Dishes, ";"),
+			formatFloat(o.Price), o.Seated.Format(time.RFC3339), o.Ordered.Format(time.RFC3339),
+			o.Ready.Format(time.RFC3339), o.Delivered.Format(time.RFC3339), formatFloat(o.WaitToOrderMin),
+			formatFloat(o.KitchenMin), formatFloat(o.DeliveryMin), formatFloat(o.TotalMin),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "orders.csv"), []string{
+		"order_id", "table", "waiter", "dishes", "price", "seated", "ordered", "ready", "delivered",
+		"wait_to_order_min", "kitchen_min", "delivery_min", "total_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, c := range rep.Courses {
+		rows = append(rows, []string{
+			c.Course, strconv.Itoa(c.Served), formatFloat(c.AvgEatMin), formatFloat(c.P50GapMin),
+			formatFloat(c.P90GapMin), formatFloat(c.MaxGapMin),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "courses.csv"), []string{
+		"course", "served", "avg_eat_min", "p50_gap_min", "p90_gap_min", "max_gap_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, ing := range rep.Inventory {
+		rows = append(rows, []string{
+			ing.Ingredient, ing.Unit, formatFloat(ing.Cost), formatFloat(ing.Opening), formatFloat(ing.Used),
+			formatFloat(ing.Wasted), formatFloat(ing.Closing), formatFloat(ing.Shortage), formatFloat(ing.Purchase),
+			formatFloat(ing.UsedCost), formatOptionalTime(ing.OutAt),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "inventory.csv"), []string{
+		"ingredient", "unit", "cost", "opening", "used", "wasted", "closing", "shortage", "purchase",
+		"used_cost", "out_at",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, b := range rep.Bookings {
+		rows = append(rows, []string{
+			strconv.Itoa(b.Booking), b.Slot.Format(time.RFC3339), strconv.Itoa(b.Size), strconv.Itoa(b.Table),
+			b.Status, formatOptionalTime(b.Arrived), formatFloat(b.LateMin), strconv.Itoa(b.SeatedTable), formatFloat(b.WaitMin),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "bookings.csv"), []string{
+		"booking", "slot", "size", "table", "status", "arrived", "late_min", "seated_table", "wait_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, inc := range rep.Incidents {
+		for _, ph := range inc.Phases {
+			rows = append(rows, []string{
+				strconv.Itoa(inc.ID), inc.Kind, inc.Title, ph.Phase, ph.From.Format(time.RFC3339),
+				ph.To.Format(time.RFC3339), strconv.Itoa(ph.PartiesArrived), strconv.Itoa(ph.PartiesLost),
+				formatFloat(ph.LostPct), formatFloat(ph.LostRevenue), strconv.Itoa(ph.OrdersServed),
+				formatFloat(ph.OrdersPerHour), formatFloat(ph.Revenue), formatFloat(ph.P50TotalMin),
+				formatFloat(ph.P90TotalMin), formatFloat(ph.P90KitchenMin),
+			})
+		}
+	}
+	err = writeCSV(filepath.Join(dir, "incidents.csv"), []string{
+		"incident", "kind", "title", "phase", "from", "to", "parties_arrived", "parties_lost", "lost_pct",
+		"lost_revenue", "orders_served", "orders_per_hour", "revenue", "p50_total_min", "p90_total_min",
+		"p90_kitchen_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, o := range rep.Offsite {
+		rows = append(rows, []string{
+			o.Channel, strconv.Itoa(o.Orders), strconv.Itoa(o.Refused), strconv.Itoa(o.Handed),
+			formatFloat(o.Revenue), formatFloat(o.OnTimePct), formatFloat(o.AvgLeadMin), formatFloat(o.P90LeadMin),
+			formatFloat(o.AvgLateMin),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "offsite.csv"), []string{
+		"channel", "orders", "refused", "handed_over", "revenue", "on_time_pct", "avg_lead_min", "p90_lead_min",
+		"avg_late_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, z := range rep.Zones {
+		rows = append(rows, []string{
+			z.Zone, strconv.Itoa(z.Deliveries), formatFloat(z.OnTimePct), formatFloat(z.AvgTravelMin),
+			formatFloat(z.P90TravelMin),
+		})
+	}
+	err = writeCSV(filepath.Join(dir, "zones.csv"), []string{
+		"zone", "deliveries", "on_time_pct", "avg_travel_min", "p90_travel_min",
+	}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = nil
+	for _, c := range rep.Checks {
+		var promos, payments []string
+		for _, line := range c.Lines {
+			if line.Promo != "" {
+				promos = append(promos, line.Dish+":"+line.Promo)
+			}
+		}
+		for _, pay := range c.Payments {
+			payments = append(payments, pay.Method+":"+formatFloat(pay.Amount+pay.Tip))
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(c.Party), strconv.Itoa(c.Table), strconv.Itoa(c.Waiter), strconv.Itoa(c.Guests),
+			c.Paid.Format(time.RFC3339), strconv.Itoa(len(c.Lines)), formatFloat(c.Gross), formatFloat(c.Discounts),
+			strings.Join(promos, ";"), formatFloat(c.Service), formatFloat(c.VAT), formatFloat(c.Total),
+			formatFloat(c.Tip), strings.Join(payments, ";"),
+		})
+	}
+	return writeCSV(filepath.Join(dir, "checks.csv"), []string{
+		"party", "table", "waiter", "guests", "paid", "items", "gross", "discounts", "promotions",
+		"service_charge", "vat", "total", "tip", "payments",
+	}, rows)
+}
+
+func saveResults(s *Stats, exportDir string) {
+	if exportDir == "-" || exportDir == "" {
+		return
+	}
+	if err := exportResults(s.Snapshot().report(), exportDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Результаты сохранены в %s\n", exportDir)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Каждый каталог testdata/<случай> содержит сценарий с зафиксированным seed
+// и эталоны: журнал событий и отчёт смены. После намеренных изменений модели
+// эталоны переписываются флагом -update:
+//
+//	GO111MODULE=off go test -run TestGolden . -update
+
+var update = flag.Bool("update", false, "переписать эталоны в testdata текущими результатами")
+
+// goldenDay — день, на который кладутся смены из testdata, чтобы время
+// в эталонах не зависело от даты запуска
+var goldenDay = time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+// goldenCases возвращает каталоги testdata/<случай> со сценарием
+func goldenCases(t *testing.T) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "*", "scenario.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatal("в testdata нет случаев (<случай>/scenario.json)")
+	}
+	dirs := make([]string, len(paths))
+	for i, path := range paths {
+		dirs[i] = filepath.Dir(path)
+	}
+	return dirs
+}
+
+// runCase проводит смену из dir/scenario.json; shift сдвигает seed сценария.
+// Возвращает журнал событий и отчёт смены
+func runCase(t *testing.T, dir string, shift int64) ([]byte, RunReport) {
+	t.Helper()
+	sc, err := loadScenario(filepath.Join(dir, "scenario.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Seed == 0 {
+		t.Fatal("в сценарии из testdata нужен seed")
+	}
+	cfg, err := sc.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Seed += shift
+	cfg.Day = goldenDay
+	cfg.Quiet = true
+	cfg.Realtime = false
+
+	var log bytes.Buffer
+	r := newRestaurant(cfg)
+	r.events = json.NewEncoder(&log)
+	r.run(context.Background())
+	return log.Bytes(), r.stats.Snapshot().report()
+}
+
+// TestGolden сверяет журнал и отчёт каждого случая с эталонами, а статистику,
+// собранную из журнала, — с живой
+func TestGolden(t *testing.T) {
+	for _, dir := range goldenCases(t) {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			t.Parallel()
+			log, rep := runCase(t, dir, 0)
+			report, err := json.MarshalIndent(rep, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, filepath.Join(dir, "events.jsonl"), log)
+			compareGolden(t, filepath.Join(dir, "report.json"), report)
+
+			events, err := readEvents(bytes.NewReader(log))
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed := newStats()
+			for _, ev := range events {
+				replayed.apply(ev)
+			}
+			again, err := json.MarshalIndent(replayed.Snapshot().report(), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, report) {
+				t.Error("статистика, собранная из журнала, расходится с живой")
+			}
+		})
+	}
+}
+
+// compareGolden сверяет результат с эталоном или, с -update, переписывает эталон
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("нет эталона %s (запустите с -update)", path)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Errorf("%s: расхождение в строке %d\n        эталон: %s\n        сейчас: %s",
+				path, i+1, shorten(w, 160), shorten(g, 160))
+			return
+		}
+	}
+	t.Errorf("%s: расхождение", path)
+}
+
+func shorten(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
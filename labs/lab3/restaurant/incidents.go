@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// === Происшествия ===
+// Сценарий может расписать, что пойдёт не так по ходу смены: сотрудник уходит
+// домой (staff_out) или приходит на подмогу (staff_in), станция кухни
+// ломается (station_down), приезжает автобус с туристами (rush), блюда
+// снимают с меню (menu_off). Состав меняется через график: ушедшему
+// сдвигают конец смены, подмога выходит отдельной сменой, и оба доделывают
+// начатое, как при обычной пересменке. Остальное включает и выключает
+// процесс происшествия. Происшествия начинаются и заканчиваются до последних
+// заказов; прерванная смена заканчивает их сразу, чтобы кухня доработала.
+
+const (
+	incidentStaffOut    = "staff_out"
+	incidentStaffIn     = "staff_in"
+	incidentStationDown = "station_down"
+	incidentRush        = "rush"
+	incidentMenuOff     = "menu_off"
+)
+
+// Incident — происшествие из сценария; время — от полуночи дня смены
+type Incident struct {
+	ID       int
+	Kind     string
+	Title    string // пусто — описание составляется по виду
+	At       time.Duration
+	Until    time.Duration // 0 — до конца дня
+	Role     string        // staff_out, staff_in
+	Count    int           // сотрудники или компании (rush)
+	Station  string        // station_down
+	Capacity int           // station_down: сколько мест на станции остаётся
+	Size     int           // rush: гостей в компании; 0 — как у обычных гостей
+	Dishes   []string      // menu_off
+}
+
+// incidentRun — происшествие в конкретной смене
+type incidentRun struct {
+	Incident
+	start, end time.Time      // end нулевое — до конца дня
+	gone       []*StaffMember // staff_out: кого отпустили домой
+}
+
+// title описывает происшествие для журнала и отчёта
+func (inc *incidentRun) title() string {
+	if inc.Title != "" {
+		return inc.Title
+	}
+	switch inc.Kind {
+	case incidentStaffOut:
+		var names []string
+		for _, m := range inc.gone {
+			names = append(names, m.title())
+		}
+		if len(names) == 0 {
+			return "отпустить домой некого"
+		}
+		return "ушли домой: " + strings.Join(names, ", ")
+	case incidentStaffIn:
+		return fmt.Sprintf("подмога: %s, %d", roleTitles[inc.Role], inc.Count)
+	case incidentStationDown:
+		if inc.Capacity == 0 {
+			return fmt.Sprintf("станция «%s» не работает", inc.Station)
+		}
+		return fmt.Sprintf("станция «%s» работает частично, мест: %d", inc.Station, inc.Capacity)
+	case incidentRush:
+		return fmt.Sprintf("наплыв гостей, компаний: %d", inc.Count)
+	default:
+		return "сняты с меню: " + strings.Join(inc.Dishes, ", ")
+	}
+}
+
+// planIncidents раскладывает происшествия смены: подмога становится
+// отдельной строкой графика, остальные получают время начала и конца
+func planIncidents(cfg SimConfig, day time.Time) ([]ShiftPlan, []*incidentRun) {
+	roster := append([]ShiftPlan(nil), cfg.Roster...)
+	var runs []*incidentRun
+	for _, inc := range cfg.Incidents {
+		run := &incidentRun{Incident: inc, start: day.Add(inc.At)}
+		if inc.Until > 0 {
+			run.end = day.Add(inc.Until)
+		}
+		if inc.Kind == incidentStaffIn {
+			end := inc.Until
+			if end == 0 {
+				end = cfg.OpenAt + cfg.Duration
+			}
+			roster = append(roster, ShiftPlan{Name: "подмога", Role: inc.Role, Count: inc.Count, Start: inc.At, End: end})
+		}
+		runs = append(runs, run)
+	}
+	return roster, runs
+}
+
+// sendHome выбирает, кто уйдёт домой: последних по номеру из тех, кто в это
+// время на смене, но так, чтобы до закрытия остался хоть один сотрудник той
+// же роли, а повара, которые остаются, умели вести все станции. Уход
+// оформляется концом смены.
+func (r *Restaurant) sendHome(inc *incidentRun) {
+	for i := len(r.staff) - 1; i >= 0 && len(inc.gone) < inc.Count; i-- {
+		m := r.staff[i]
+		if m.Role != inc.Role || m.Start.After(inc.start) || !m.End.After(inc.start) {
+			continue
+		}
+		if !m.End.Before(r.closeTime) && !r.closesWithout(m) {
+			continue
+		}
+		m.End = inc.start
+		inc.gone = append(inc.gone, m)
+	}
+}
+
+// closesWithout сообщает, справятся ли с закрытием без сотрудника m
+func (r *Restaurant) closesWithout(m *StaffMember) bool {
+	var closers []*StaffMember
+	for _, o := range r.staff {
+		if o != m && o.Role == m.Role && !o.End.Before(r.closeTime) {
+			closers = append(closers, o)
+		}
+	}
+	if len(closers) == 0 {
+		return false
+	}
+	if m.Role != "chef" {
+		return true
+	}
+	for _, st := range kitchenStations {
+		covered := false
+		for _, o := range closers {
+			covered = covered || hasSkill(o.Skills, st.Name)
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// incident включает происшествие в назначенное время и выключает по окончании
+func (r *Restaurant) incident(inc *incidentRun) {
+	if !r.pause(inc.start.Sub(r.clock.now)) {
+		return
+	}
+	r.logf("[%s] Происшествие #%d: %s\n", formatTime(r.clock.now), inc.ID, inc.title())
+	r.emit(Event{Type: evIncidentStarted, Incident: inc.ID, Reason: inc.Kind})
+	var restore func()
+	switch inc.Kind {
+	case incidentStationDown:
+		st := r.kitchen.station(inc.Station)
+		capacity := st.Capacity
+		st.Capacity = inc.Capacity
+		restore = func() {
+			st.Capacity = capacity
+			r.kitchen.cond.Broadcast()
+		}
+	case incidentMenuOff:
+		for _, name := range inc.Dishes {
+			r.disabled[name] = true
+		}
+		restore = func() {
+			for _, name := range inc.Dishes {
+				delete(r.disabled, name)
+			}
+		}
+	case incidentRush:
+		r.rush(inc)
+	}
+	if inc.end.IsZero() {
+		return
+	}
+	r.pause(inc.end.Sub(r.clock.now))
+	if restore != nil {
+		restore()
+	}
+	r.logf("[%s] Происшествие #%d закончилось\n", formatTime(r.clock.now), inc.ID)
+	r.emit(Event{Type: evIncidentEnded, Incident: inc.ID, Reason: inc.Kind})
+}
+
+// rush приводит компании наплыва равномерно за время происшествия
+func (r *Restaurant) rush(inc *incidentRun) {
+	gap := inc.end.Sub(inc.start) / time.Duration(inc.Count)
+	for i := 0; i < inc.Count && !r.interrupted; i++ {
+		size := inc.Size
+		if size == 0 {
+			size = partySizes[r.incRng.Intn(len(partySizes))]
+		}
+		p := &Party{
+			ID:       int(atomic.AddInt32(&r.partyIDCounter, 1)),
+			Size:     size,
+			Patience: r.patience.sample(r.incRng),
+			Arrived:  r.clock.now,
+			cond:     simCond{clock: r.clock},
+		}
+		r.clock.Go(func() { r.party(p) })
+		r.pause(gap)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// invariantSeeds — сколько смен на каждый случай из testdata проверяет
+// TestInvariants: seed сценария и следующие за ним
+const invariantSeeds = 5
+
+// TestInvariants проводит сценарии из testdata с разными seed и проверяет
+// свойства, которые обязаны выполняться при любом сценарии
+func TestInvariants(t *testing.T) {
+	for _, dir := range goldenCases(t) {
+		for shift := int64(0); shift < invariantSeeds; shift++ {
+			dir, shift := dir, shift
+			t.Run(fmt.Sprintf("%s/seed+%d", filepath.Base(dir), shift), func(t *testing.T) {
+				t.Parallel()
+				log, rep := runCase(t, dir, shift)
+				events, err := readEvents(bytes.NewReader(log))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, err := range checkInvariants(events, rep) {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+// checkInvariants проверяет журнал смены и отчёт по нему на свойства,
+// которые не зависят ни от сценария, ни от seed:
+//   - события идут по времени, журнал заканчивается run_finished;
+//   - каждый принятый заказ завершается ровно одним исходом: гости
+//     оплатили счёт или ушли, не дождавшись заказа;
+//   - каждый заказ навынос и с доставкой отдан ровно один раз;
+//   - за стол, придержанный под бронь, сажают только гостей этой брони;
+//   - дела закреплённого стола делает только его официант, и при посадке
+//     у официанта не больше max_tables столов;
+//   - пока станция сломана, на ней не начинают готовить сверх оставшихся
+//     мест, а снятые с меню блюда не попадают в новые заказы;
+//   - блюдо снимают с продажи из-за склада не больше одного раза, и после
+//     этого его не заказывают до конца смены;
+//   - выручка в отчёте равна сумме цен поданных заказов, сумме по блюдам
+//     без выноса и доставки, сумме по столам и, если есть касса, сумме
+//     счетов по ценам меню;
+//   - заказы не принимают после последних заказов, а готовить не начинают
+//     после закрытия кухни;
+//   - на хронологии кухни повар готовит не больше одного блюда за раз.
+func checkInvariants(events []Event, rep RunReport) []error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	placed := make(map[int]Event)
+	var orders []int
+	delivered := make(map[int]bool)
+	paid := make(map[int]int)      // компания → оплаченные счета
+	abandoned := make(map[int]int) // заказ → уходы гостей, не дождавшихся его
+	var gross float64
+	checks := 0
+	offsite := make(map[int]Event)
+	var offsiteOrders []int
+	handed := make(map[int]int)
+	held := make(map[int]int)        // стол → бронь, под которую он придержан
+	holdOf := make(map[int]int)      // бронь → придержанный стол
+	serving := make(map[int]int)     // компания → закреплённый официант
+	load := make(map[int]int)        // официант → закреплённые столы
+	capacity := make(map[string]int) // станция → мест сейчас
+	cooking := make(map[string]int)  // станция → блюд готовится
+	disabled := make(map[string]int) // блюдо → сколько происшествий его сняли
+	planned := make(map[int]IncidentInfo)
+	soldOut := make(map[string]time.Time) // блюдо → когда на него не хватило продуктов
+	var last, doorsClosed, kitchenClosed time.Time
+	offMenu := func(ev Event) {
+		for _, name := range ev.Dishes {
+			if disabled[name] > 0 {
+				fail("'%s' в заказе #%d в %s, когда блюдо снято с меню", name, ev.Order, formatTime(ev.Time))
+			}
+			// заказ, забравший последние продукты, снимает блюдо с продажи раньше,
+			// чем попадает в журнал, поэтому в тот же момент блюдо ещё заказывают
+			if at, ok := soldOut[name]; ok && ev.Time.After(at) {
+				fail("'%s' в заказе #%d в %s, когда блюдо снято с продажи", name, ev.Order, formatTime(ev.Time))
+			}
+		}
+	}
+	for i, ev := range events {
+		if ev.Time.Before(last) {
+			fail("событие %d (%s) раньше предыдущего", i+1, ev.Type)
+		}
+		last = ev.Time
+		if ev.Waiter != 0 && ev.Type != evPartySeated && ev.Type != evTableReassigned {
+			party := ev.Party
+			if party == 0 {
+				party = placed[ev.Order].Party
+			}
+			if w, ok := serving[party]; ok && w != ev.Waiter {
+				fail("%s гостей #%d в %s сделал официант %d, а стол закреплён за официантом %d",
+					ev.Type, party, formatTime(ev.Time), ev.Waiter, w)
+			}
+		}
+		switch ev.Type {
+		case evRunStarted:
+			for _, st := range ev.Run.Stations {
+				capacity[st.Name] = st.Capacity
+			}
+			for _, inc := range ev.Run.Incidents {
+				planned[inc.ID] = inc
+			}
+		case evIncidentStarted, evIncidentEnded:
+			inc := planned[ev.Incident]
+			switch {
+			case inc.Kind == incidentStationDown && ev.Type == evIncidentStarted:
+				capacity[inc.Station], inc.Capacity = inc.Capacity, capacity[inc.Station]
+				planned[inc.ID] = inc // вернуть прежнее число мест по окончании
+			case inc.Kind == incidentStationDown:
+				capacity[inc.Station] = inc.Capacity
+			case inc.Kind == incidentMenuOff:
+				for _, name := range inc.Dishes {
+					if ev.Type == evIncidentStarted {
+						disabled[name]++
+					} else {
+						disabled[name]--
+					}
+				}
+			}
+		case evDoorsClosed:
+			doorsClosed = ev.Time
+		case evKitchenClosed:
+			kitchenClosed = ev.Time
+		case evStockOut:
+			if _, ok := soldOut[ev.Dish]; ok {
+				fail("'%s' снова снято с продажи в %s", ev.Dish, formatTime(ev.Time))
+			}
+			soldOut[ev.Dish] = ev.Time
+		case evOrderPlaced:
+			if !doorsClosed.IsZero() {
+				fail("заказ #%d принят в %s, после последних заказов в %s",
+					ev.Order, formatTime(ev.Time), formatTime(doorsClosed))
+			}
+			offMenu(ev)
+			placed[ev.Order] = ev
+			orders = append(orders, ev.Order)
+		case evOffsiteOrdered:
+			if !doorsClosed.IsZero() {
+				fail("заказ #%d %s принят в %s, после последних заказов в %s",
+					ev.Order, channelTitles[ev.Channel], formatTime(ev.Time), formatTime(doorsClosed))
+			}
+			offMenu(ev)
+			offsite[ev.Order] = ev
+			offsiteOrders = append(offsiteOrders, ev.Order)
+		case evOffsiteHandedOver:
+			handed[ev.Order]++
+		case evReservationHeld:
+			held[ev.Table] = ev.Booking
+			holdOf[ev.Booking] = ev.Table
+		case evReservationReleased:
+			if held[ev.Table] == ev.Booking {
+				delete(held, ev.Table)
+			}
+		case evPartySeated:
+			if b, ok := held[ev.Table]; ok && b != ev.Booking {
+				fail("гостей #%d посадили в %s за стол %d, придержанный под бронь #%d",
+					ev.Party, formatTime(ev.Time), ev.Table, b)
+			}
+			if t, ok := holdOf[ev.Booking]; ok && held[t] == ev.Booking {
+				delete(held, t)
+			}
+			if ev.Waiter != 0 {
+				serving[ev.Party] = ev.Waiter
+				load[ev.Waiter]++
+				if limit := rep.Balance.MaxTables; limit > 0 && load[ev.Waiter] > limit {
+					fail("в %s официанту %d дали %d-й стол при ограничении %d",
+						formatTime(ev.Time), ev.Waiter, load[ev.Waiter], limit)
+				}
+			}
+		case evTableReassigned:
+			load[serving[ev.Party]]--
+			serving[ev.Party] = ev.Waiter
+			load[ev.Waiter]++
+		case evTableCleared:
+			if w, ok := serving[ev.Party]; ok {
+				load[w]--
+				delete(serving, ev.Party)
+			}
+		case evCookingStarted:
+			if !kitchenClosed.IsZero() {
+				fail("'%s' для заказа #%d начали готовить в %s, после закрытия кухни",
+					ev.Dish, ev.Order, formatTime(ev.Time))
+			}
+			if cooking[ev.Station] >= capacity[ev.Station] {
+				fail("'%s' для заказа #%d начали готовить в %s, когда на станции «%s» занято %d мест из %d",
+					ev.Dish, ev.Order, formatTime(ev.Time), ev.Station, cooking[ev.Station], capacity[ev.Station])
+			}
+			cooking[ev.Station]++
+		case evCookingFinished:
+			cooking[ev.Station]--
+		case evOrderDelivered:
+			delivered[ev.Order] = true
+		case evPartyAbandoned:
+			if ev.Order != 0 {
+				abandoned[ev.Order]++
+			}
+		case evBillPaid:
+			paid[ev.Party]++
+			if ev.Check != nil {
+				gross += ev.Check.Gross
+				checks++
+			}
+		}
+	}
+	if len(events) == 0 || events[len(events)-1].Type != evRunFinished {
+		fail("журнал не заканчивается событием %s", evRunFinished)
+	}
+
+	var revenue float64
+	for _, id := range orders {
+		ev := placed[id]
+		if n := paid[ev.Party] + abandoned[id]; n != 1 {
+			fail("у заказа #%d %d исходов вместо одного (оплат: %d, уходов: %d)",
+				id, n, paid[ev.Party], abandoned[id])
+		}
+		if delivered[id] {
+			revenue += ev.Amount
+		}
+	}
+	var offsiteRevenue float64
+	for _, id := range offsiteOrders {
+		if handed[id] != 1 {
+			fail("заказ #%d %s отдан %d раз вместо одного", id, channelTitles[offsite[id].Channel], handed[id])
+		}
+		offsiteRevenue += offsite[id].Amount
+	}
+	if math.Abs(offsiteRevenue-rep.Summary.OffsiteRevenue) > 0.005 {
+		fail("выручка навынос и с доставкой %.2f не равна сумме отданных заказов %.2f",
+			rep.Summary.OffsiteRevenue, offsiteRevenue)
+	}
+
+	var byDish, byTable float64
+	for _, d := range rep.Dishes {
+		byDish += d.Revenue
+	}
+	for _, t := range rep.Tables {
+		byTable += t.Revenue
+	}
+	type total struct {
+		name  string
+		value float64
+	}
+	// блюда продаются и в зале, и навынос; столы и счета — только зал
+	totals := []total{{"поданных заказов", revenue}, {"по блюдам", byDish - offsiteRevenue}, {"по столам", byTable}}
+	if checks > 0 {
+		totals = append(totals, total{"счетов по ценам меню", gross})
+	}
+	for _, t := range totals {
+		if math.Abs(t.value-rep.Summary.Revenue) > 0.005 {
+			fail("выручка %.2f не равна сумме %s %.2f", rep.Summary.Revenue, t.name, t.value)
+		}
+	}
+
+	for _, lane := range buildTimeline(events).Chefs {
+		cooks := lane.cooks()
+		for i := 1; i < len(cooks); i++ {
+			if prev, cur := cooks[i-1], cooks[i]; cur.Start.Before(prev.End) {
+				fail("%s начал '%s' для заказа #%d в %s, не доготовив '%s' для заказа #%d",
+					lane.Title, cur.Dish, cur.Order, formatTime(cur.Start), prev.Dish, prev.Order)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,114 @@
+package main
+
+import "sort"
+
+// === Склад ===
+// Продукты резервируются, когда официант принимает заказ, и списываются,
+// когда повар берёт блюдо в работу. Блюдо снимают с продажи («86»), как
+// только на порцию не хватает хотя бы одного продукта, и до конца смены
+// не возвращают, даже если отменённый заказ освободит резерв.
+
+// Ingredient — продукт на складе; Cost — цена за единицу
+type Ingredient struct {
+	Name  string  `json:"name"`
+	Unit  string  `json:"unit"`
+	Cost  float64 `json:"cost"`
+	Stock float64 `json:"stock"` // остаток на открытие
+}
+
+type Inventory struct {
+	stock    map[string]float64
+	reserved map[string]float64
+	soldOut  map[string]bool
+}
+
+// newInventory возвращает nil, если остатки не заданы: тогда продукты не кончаются
+func newInventory(ingredients []Ingredient) *Inventory {
+	if len(ingredients) == 0 {
+		return nil
+	}
+	inv := &Inventory{
+		stock:    make(map[string]float64),
+		reserved: make(map[string]float64),
+		soldOut:  make(map[string]bool),
+	}
+	for _, ing := range ingredients {
+		inv.stock[ing.Name] = ing.Stock
+	}
+	return inv
+}
+
+// recipeIngredients — продукты рецепта по алфавиту, чтобы прогон повторялся
+func recipeIngredients(d Dish) []string {
+	names := make([]string, 0, len(d.Recipe))
+	for name := range d.Recipe {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shortOf возвращает продукт, которого не хватает на порцию, или пустую строку
+func (inv *Inventory) shortOf(d Dish) string {
+	if inv == nil {
+		return ""
+	}
+	for _, name := range recipeIngredients(d) {
+		have, tracked := inv.stock[name]
+		if tracked && have-inv.reserved[name] < d.Recipe[name]-1e-9 {
+			return name
+		}
+	}
+	return ""
+}
+
+func (r *Restaurant) available(d Dish) bool {
+	if r.disabled[d.Name] || r.stock != nil && r.stock.soldOut[d.Name] {
+		return false
+	}
+	return r.stock.shortOf(d) == ""
+}
+
+// reserveDish откладывает продукты под порцию и снимает с продажи блюда,
+// на которые их больше не хватает
+func (r *Restaurant) reserveDish(d Dish) {
+	if r.stock == nil {
+		return
+	}
+	for name, qty := range d.Recipe {
+		r.stock.reserved[name] += qty
+	}
+	for _, dish := range r.menu {
+		short := r.stock.shortOf(dish)
+		if short == "" || r.stock.soldOut[dish.Name] {
+			continue
+		}
+		r.stock.soldOut[dish.Name] = true
+		r.logf("[%s] Кухня: '%s' больше нет — закончился продукт «%s»\n", formatTime(r.clock.now), dish.Name, short)
+		r.emit(Event{Type: evStockOut, Dish: dish.Name, Ingredient: short})
+	}
+}
+
+// releaseDish возвращает резерв отменённой порции; снятые с продажи блюда
+// остаются снятыми
+func (r *Restaurant) releaseDish(d Dish) {
+	if r.stock == nil {
+		return
+	}
+	for name, qty := range d.Recipe {
+		r.stock.reserved[name] -= qty
+	}
+}
+
+// consumeDish списывает зарезервированные продукты со склада
+func (r *Restaurant) consumeDish(d Dish) {
+	if r.stock == nil {
+		return
+	}
+	for name, qty := range d.Recipe {
+		if _, tracked := r.stock.stock[name]; tracked {
+			r.stock.stock[name] -= qty
+		}
+		r.stock.reserved[name] -= qty
+	}
+}
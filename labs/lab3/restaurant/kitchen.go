@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// === Кухня: станции и оборудование ===
+
+// Станции кухни и число блюд, которые на них можно готовить одновременно
+// (места на гриле, конфорки, духовка)
+var kitchenStations = []Station{
+	{Name: stationGrill, Capacity: 2},
+	{Name: stationStove, Capacity: 4},
+	{Name: stationCold, Capacity: 2},
+	{Name: stationPastry, Capacity: 1},
+}
+
+// Навыки поваров назначаются по кругу
+var chefSkillSets = [][]string{
+	{stationGrill, stationStove},
+	{stationStove, stationCold},
+	{stationCold, stationPastry},
+	{stationGrill, stationStove, stationCold, stationPastry},
+}
+
+type Station struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+	busy     int
+}
+
+// Kitchen — очередь блюд к станциям. Повар берёт первое блюдо, которое умеет
+// готовить и для которого на станции есть свободное место.
+type Kitchen struct {
+	stations []*Station
+	tickets  []kitchenTicket
+	policy   dispatchPolicy
+	closed   bool
+	cond     simCond
+}
+
+func newKitchen(c *simClock, policy dispatchPolicy) *Kitchen {
+	k := &Kitchen{cond: simCond{clock: c}, policy: policy}
+	for _, st := range kitchenStations {
+		st := st
+		k.stations = append(k.stations, &st)
+	}
+	return k
+}
+
+func (k *Kitchen) station(name string) *Station {
+	for _, st := range k.stations {
+		if st.Name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+func (k *Kitchen) Put(t kitchenTicket) {
+	k.tickets = append(k.tickets, t)
+	k.cond.Broadcast()
+}
+
+func (k *Kitchen) Len() int { return len(k.tickets) }
+
+func (k *Kitchen) Close() {
+	k.closed = true
+	k.cond.Broadcast()
+}
+
+// take ждёт блюдо, которое повар с навыками skills может начать готовить
+// прямо сейчас, и занимает под него место на станции. Если таких блюд
+// несколько, выбор делает политика кухни. Отменённые блюда отдаются без
+// станции — их нужно просто снять. Ненулевой deadline ограничивает ожидание.
+func (k *Kitchen) take(skills []string, deadline time.Time) (kitchenTicket, *Station, waitResult) {
+	for {
+		var candidates []int
+		for i, t := range k.tickets {
+			if !hasSkill(skills, t.dish.Station) {
+				continue
+			}
+			if t.order.cancelled {
+				k.tickets = append(k.tickets[:i], k.tickets[i+1:]...)
+				return t, nil, waitOK
+			}
+			st := k.station(t.dish.Station)
+			if st.busy < st.Capacity {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) > 0 {
+			i := k.policy.pick(k.tickets, candidates)
+			t := k.tickets[i]
+			st := k.station(t.dish.Station)
+			st.busy++
+			t.order.started++
+			k.tickets = append(k.tickets[:i], k.tickets[i+1:]...)
+			return t, st, waitOK
+		}
+		if k.closed && len(k.tickets) == 0 {
+			return kitchenTicket{}, nil, waitClosed
+		}
+		if deadline.IsZero() {
+			k.cond.Wait()
+		} else if !deadline.After(k.cond.clock.now) || !k.cond.WaitTimeout(deadline.Sub(k.cond.clock.now)) {
+			return kitchenTicket{}, nil, waitTimeout
+		}
+	}
+}
+
+func (k *Kitchen) release(st *Station) {
+	st.busy--
+	k.cond.Broadcast()
+}
+
+func (k *Kitchen) queued(station string) int {
+	n := 0
+	for _, t := range k.tickets {
+		if t.dish.Station == station {
+			n++
+		}
+	}
+	return n
+}
+
+// === Политики кухни ===
+
+// dispatchPolicy выбирает, какое из блюд candidates (индексы в очереди
+// tickets, по порядку поступления) повар начнёт готовить
+type dispatchPolicy interface {
+	Name() string
+	pick(tickets []kitchenTicket, candidates []int) int
+}
+
+// fifoPolicy — в порядке поступления
+type fifoPolicy struct{}
+
+func (fifoPolicy) Name() string { return "fifo" }
+func (fifoPolicy) pick(tickets []kitchenTicket, candidates []int) int {
+	return candidates[0]
+}
+
+// sptPolicy — сначала самые быстрые блюда
+type sptPolicy struct{}
+
+func (sptPolicy) Name() string { return "spt" }
+func (sptPolicy) pick(tickets []kitchenTicket, candidates []int) int {
+	best := candidates[0]
+	for _, i := range candidates[1:] {
+		if tickets[i].dish.avgCookTime() < tickets[best].dish.avgCookTime() {
+			best = i
+		}
+	}
+	return best
+}
+
+// eddPolicy — сначала заказы с самым ранним сроком готовности
+type eddPolicy struct{}
+
+func (eddPolicy) Name() string { return "edd" }
+func (eddPolicy) pick(tickets []kitchenTicket, candidates []int) int {
+	best := candidates[0]
+	for _, i := range candidates[1:] {
+		if tickets[i].order.Due.Before(tickets[best].order.Due) {
+			best = i
+		}
+	}
+	return best
+}
+
+// batchPolicy — сначала дорабатываем заказы, которые уже начали, чтобы все
+// блюда стола выходили вместе
+type batchPolicy struct{}
+
+func (batchPolicy) Name() string { return "batch" }
+func (batchPolicy) pick(tickets []kitchenTicket, candidates []int) int {
+	for _, i := range candidates {
+		if tickets[i].order.started > 0 {
+			return i
+		}
+	}
+	return candidates[0]
+}
+
+// vipPolicy — сначала VIP-столы, остальные по порядку
+type vipPolicy struct{}
+
+func (vipPolicy) Name() string { return "vip" }
+func (vipPolicy) pick(tickets []kitchenTicket, candidates []int) int {
+	for _, i := range candidates {
+		if tickets[i].order.VIP {
+			return i
+		}
+	}
+	return candidates[0]
+}
+
+var dispatchPolicies = []dispatchPolicy{fifoPolicy{}, sptPolicy{}, eddPolicy{}, batchPolicy{}, vipPolicy{}}
+
+func findPolicy(name string) (dispatchPolicy, error) {
+	for _, p := range dispatchPolicies {
+		if p.Name() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("неизвестная политика кухни %q", name)
+}
+
+func (d Dish) avgCookTime() time.Duration {
+	return time.Duration(d.MinCookTime+d.MaxCookTime) * time.Minute / 2
+}
+
+func hasSkill(skills []string, station string) bool {
+	for _, s := range skills {
+		if s == station {
+			return true
+		}
+	}
+	return false
+}
+
+// chefSkills раздаёт навыки по кругу и следит, чтобы каждую станцию
+// умел вести хотя бы один повар
+func chefSkills(numChefs int) [][]string {
+	skills := make([][]string, numChefs)
+	for i := range skills {
+		skills[i] = append([]string(nil), chefSkillSets[i%len(chefSkillSets)]...)
+	}
+	for _, st := range kitchenStations {
+		covered := false
+		for _, s := range skills {
+			if hasSkill(s, st.Name) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			skills[0] = append(skills[0], st.Name)
+		}
+	}
+	return skills
+}
+
+// === Логика поваров ===
+
+func chef(m *StaffMember, r *Restaurant) {
+	chefID := m.ID
+	if !r.beginShift(m) {
+		return
+	}
+	for {
+		r.setChefDish(chefID, "")
+		if !r.stayOnDuty(m) {
+			return
+		}
+		ticket, station, res := r.kitchen.take(m.Skills, r.dutyDeadline(m))
+		if res == waitTimeout {
+			continue
+		}
+		if res == waitClosed {
+			r.endShift(m)
+			return
+		}
+		dish := ticket.dish
+		order := ticket.order
+		if station == nil {
+			r.logf("[%s] Повар %d снял '%s' (заказ #%d) — гости ушли\n",
+				formatTime(r.clock.now), chefID, dish.Name, order.OrderID)
+			r.releaseDish(dish)
+			r.emit(Event{Type: evDishDiscarded, Ticket: ticket.id, Order: order.OrderID, Chef: chefID,
+				Dish: dish.Name, Reason: "cancelled"})
+			continue
+		}
+
+		r.consumeDish(dish)
+		cookMinutes := dish.MinCookTime + r.rng.Intn(dish.MaxCookTime-dish.MinCookTime+1)
+		virtualCookDuration := time.Duration(cookMinutes) * time.Minute
+		started := r.clock.now
+
+		r.logf("[%s] Повар %d начал готовить '%s' (%s) %s (заказ #%d), время: %v\n",
+			formatTime(started), chefID, dish.Name, station.Name, order.dest(), order.OrderID, virtualCookDuration)
+		r.chefDishes[chefID-1] = fmt.Sprintf("%s (%s, заказ #%d)", dish.Name, station.Name, order.OrderID)
+		r.emit(Event{Type: evCookingStarted, Ticket: ticket.id, Order: order.OrderID, Chef: chefID,
+			Dish: dish.Name, Station: station.Name})
+
+		r.clock.Sleep(virtualCookDuration)
+		r.kitchen.release(station)
+		finished := Event{Type: evCookingFinished, Ticket: ticket.id, Order: order.OrderID, Chef: chefID,
+			Dish: dish.Name, Station: station.Name, Amount: dish.BasePrice}
+		if order.cancelled {
+			r.logf("[%s] Повар %d приготовил '%s' впустую — гости стола %d ушли\n",
+				formatTime(r.clock.now), chefID, dish.Name, order.TableID)
+			finished.Reason = "wasted"
+			r.emit(finished)
+			continue
+		}
+		r.emit(finished)
+
+		order.pending--
+		if order.pending == 0 && order.offsite() {
+			r.offsiteReady(order)
+		} else if order.pending == 0 {
+			if order.course == 0 {
+				order.ReadyTime = r.clock.now
+				r.logf("[%s] Заказ #%d для стола %d готов\n", formatTime(r.clock.now), order.OrderID, order.TableID)
+				r.emit(Event{Type: evOrderReady, Order: order.OrderID, Table: order.TableID})
+			} else {
+				r.logf("[%s] Курс «%s» заказа #%d для стола %d готов\n", formatTime(r.clock.now),
+					courseTitles[order.courseName()], order.OrderID, order.TableID)
+			}
+			r.addTask(floorTask{kind: taskDeliver, party: order.party, table: order.party.Table})
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// === Вынос и доставка ===
+// Заказы навынос и с доставкой приходят своим потоком, минуя зал, и готовятся
+// на той же кухне, что и заказы столов. Гостю обещают срок: навынос — когда
+// заказ можно забрать, с доставкой — когда его привезут. За выносом гость
+// приходит к обещанному сроку и ждёт, если заказ ещё не готов; доставку
+// везёт первый свободный курьер и возвращается обратно.
+
+const (
+	channelTakeaway = "takeaway"
+	channelDelivery = "delivery"
+)
+
+var channels = []string{channelTakeaway, channelDelivery}
+
+var channelTitles = map[string]string{channelTakeaway: "навынос", channelDelivery: "доставка"}
+
+// OffsiteModel — поток заказов навынос и с доставкой; нулевая частота — канала нет
+type OffsiteModel struct {
+	TakeawayRate  float64       // заказов в час
+	DeliveryRate  float64       // заказов в час
+	TakeawayQuote time.Duration // через сколько обещают выдать заказ навынос
+	DeliveryQuote time.Duration // через сколько обещают привезти
+	MaxDishes     int
+	Couriers      int
+	Zones         []Zone
+}
+
+// Zone — район доставки: доля заказов и дорога в одну сторону
+type Zone struct {
+	Name   string
+	Share  float64
+	Travel distribution
+}
+
+// zone выбирает район доставки по долям
+func (m OffsiteModel) zone(rng *rand.Rand) *Zone {
+	var total float64
+	for _, z := range m.Zones {
+		total += z.Share
+	}
+	x := rng.Float64() * total
+	for i := range m.Zones {
+		x -= m.Zones[i].Share
+		if x < 0 {
+			return &m.Zones[i]
+		}
+	}
+	return &m.Zones[len(m.Zones)-1]
+}
+
+// offsiteOrders принимает заказы канала channel — в среднем rate в час —
+// до последних заказов
+func offsiteOrders(r *Restaurant, channel string, rate float64) {
+	lastOrders := r.closeTime.Add(-lastOrdersBeforeClose)
+	for {
+		gap := time.Duration(r.offRng.ExpFloat64() / rate * float64(time.Hour))
+		if !r.clock.now.Add(gap).Before(lastOrders) || !r.pause(gap) {
+			return
+		}
+		r.offsiteOrder(channel)
+	}
+}
+
+func (r *Restaurant) offsiteOrder(channel string) {
+	m := r.cfg.Offsite
+	now := r.clock.now
+	order := &Order{Channel: channel, StartTime: now, handoff: simCond{clock: r.clock}}
+	quote := m.TakeawayQuote
+	if channel == channelDelivery {
+		order.zone = m.zone(r.offRng)
+		quote = m.DeliveryQuote
+	}
+	zone := order.zoneName()
+
+	for n := 1 + r.offRng.Intn(m.MaxDishes); n > 0; n-- {
+		var choice []Dish
+		for _, d := range r.menu {
+			if r.available(d) {
+				choice = append(choice, d)
+			}
+		}
+		if len(choice) == 0 {
+			break
+		}
+		dish := choice[r.offRng.Intn(len(choice))]
+		r.reserveDish(dish)
+		order.Dishes = append(order.Dishes, dish)
+		order.Price += dish.BasePrice
+	}
+	if len(order.Dishes) == 0 {
+		r.logf("[%s] Заказ %s не принят — всё меню в стоп-листе\n", formatTime(now), channelTitles[channel])
+		r.emit(Event{Type: evOffsiteRefused, Channel: channel, Zone: zone, Reason: "sold_out"})
+		return
+	}
+
+	order.OrderID = int(atomic.AddInt32(&r.orderIDCounter, 1))
+	order.Promised = now.Add(quote)
+	order.Due = order.Promised
+	if order.zone != nil {
+		order.Due = order.Promised.Add(-order.zone.Travel.Mean)
+	}
+	order.courses = [][]Dish{order.Dishes}
+	order.pending = len(order.Dishes)
+	r.offsiteOpen++
+
+	promised := order.Promised
+	placed := Event{Type: evOffsiteOrdered, Order: order.OrderID, Channel: channel, Zone: zone,
+		Amount: order.Price, Promised: &promised}
+	for _, dish := range order.Dishes {
+		placed.Dishes = append(placed.Dishes, dish.Name)
+	}
+	r.emit(placed)
+	r.logf("[%s] Заказ #%d %s: %d блюд на %.2f руб., обещан к %s\n", formatTime(now), order.OrderID,
+		order.dest(), len(order.Dishes), order.Price, formatTime(order.Promised))
+	r.queueDishes(order, order.Dishes)
+	if channel == channelTakeaway {
+		r.clock.Go(func() { r.takeaway(order) })
+	}
+}
+
+func (o *Order) zoneName() string {
+	if o.zone == nil {
+		return ""
+	}
+	return o.zone.Name
+}
+
+// offsiteReady вызывается поваром, доготовившим последнее блюдо заказа
+func (r *Restaurant) offsiteReady(o *Order) {
+	o.ReadyTime = r.clock.now
+	r.logf("[%s] Заказ #%d %s готов\n", formatTime(o.ReadyTime), o.OrderID, o.dest())
+	r.emit(Event{Type: evOffsiteReady, Order: o.OrderID, Channel: o.Channel})
+	if o.Channel == channelDelivery {
+		r.couriers.Put(o)
+	} else {
+		o.handoff.Broadcast()
+	}
+}
+
+// takeaway — гость, который приходит за заказом навынос к обещанному сроку
+func (r *Restaurant) takeaway(o *Order) {
+	r.clock.SleepUntil(o.Promised)
+	for o.ReadyTime.IsZero() {
+		o.handoff.Wait()
+	}
+	r.handOver(o, 0)
+}
+
+func courier(id int, r *Restaurant) {
+	for {
+		o, ok := r.couriers.Get()
+		if !ok {
+			return
+		}
+		r.logf("[%s] Курьер %d повёз заказ #%d в район «%s»\n", formatTime(r.clock.now), id, o.OrderID, o.zone.Name)
+		r.emit(Event{Type: evCourierDeparted, Order: o.OrderID, Courier: id, Zone: o.zone.Name})
+		r.clock.Sleep(o.zone.Travel.sample(r.offRng))
+		r.handOver(o, id)
+		r.clock.Sleep(o.zone.Travel.sample(r.offRng))
+		r.logf("[%s] Курьер %d вернулся\n", formatTime(r.clock.now), id)
+		r.emit(Event{Type: evCourierReturned, Courier: id})
+	}
+}
+
+// handOver отдаёт заказ гостю: навынос — у стойки, с доставкой — курьером у двери
+func (r *Restaurant) handOver(o *Order, courierID int) {
+	o.EndTime = r.clock.now
+	status := "вовремя"
+	if late := o.EndTime.Sub(o.Promised); late > 0 {
+		status = fmt.Sprintf("опоздание %v", late.Round(time.Minute))
+	}
+	r.logf("[%s] Заказ #%d %s отдан гостю (%s)\n", formatTime(o.EndTime), o.OrderID, o.dest(), status)
+	r.emit(Event{Type: evOffsiteHandedOver, Order: o.OrderID, Channel: o.Channel, Zone: o.zoneName(),
+		Courier: courierID})
+	r.offsiteOpen--
+	r.floor.empty.Broadcast()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// === Подбор персонала ===
+// go run . optimize [флаги] перебирает составы поваров и официантов.
+// Для каждого состава проводится несколько смен с одними и теми же seed,
+// так что составы сравниваются на одинаковом потоке гостей. Лучшим считается
+// состав с наибольшей средней прибылью, у которого p90 времени обслуживания
+// (от посадки до подачи) укладывается в SLA.
+
+type CostModel struct {
+	ChefWage       float64 // руб. в час
+	WaiterWage     float64 // руб. в час
+	FoodCost       float64 // доля себестоимости продуктов в выручке
+	WalkoutPenalty float64 // руб. за каждую компанию, ушедшую ни с чем
+}
+
+func (m CostModel) profit(out shiftOutcome, chefs, waiters int) float64 {
+	wages := out.Hours * (float64(chefs)*m.ChefWage + float64(waiters)*m.WaiterWage)
+	return out.Revenue*(1-m.FoodCost) - wages - float64(out.LostParties)*m.WalkoutPenalty
+}
+
+type staffingResult struct {
+	Chefs    int
+	Waiters  int
+	Profit   estimate
+	Revenue  estimate
+	LostPct  estimate
+	P90Min   estimate
+	Feasible bool
+}
+
+// evaluateStaffing проводит runs смен для состава cfg.Chefs/cfg.Waiters
+func evaluateStaffing(cfg SimConfig, model CostModel, runs int, seed int64, workers int, sla time.Duration) staffingResult {
+	var profit, revenue, lost, p90 []float64
+	feasible := true
+	for _, out := range replicate(cfg, runs, seed, workers) {
+		profit = append(profit, model.profit(out, cfg.Chefs, cfg.Waiters))
+		revenue = append(revenue, out.Revenue)
+		lost = append(lost, out.LostPct())
+		p90 = append(p90, minutes(out.P90Total))
+		if out.Served == 0 {
+			feasible = false
+		}
+	}
+	res := staffingResult{
+		Chefs:   cfg.Chefs,
+		Waiters: cfg.Waiters,
+		Profit:  newEstimate(profit),
+		Revenue: newEstimate(revenue),
+		LostPct: newEstimate(lost),
+		P90Min:  newEstimate(p90),
+	}
+	res.Feasible = feasible && res.P90Min.Mean <= minutes(sla)
+	return res
+}
+
+// parseRange разбирает "3" или "1-6"
+func parseRange(s string) (int, int, error) {
+	from, to, found := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некорректный диапазон %q", s)
+	}
+	hi := lo
+	if found {
+		if hi, err = strconv.Atoi(to); err != nil || hi < lo {
+			return 0, 0, fmt.Errorf("некорректный диапазон %q", s)
+		}
+	}
+	return lo, hi, nil
+}
+
+func runOptimize(args []string) {
+	fs := flag.NewFlagSet("optimize", flag.ExitOnError)
+	chefsFlag := fs.String("chefs", "1-6", "диапазон количества поваров")
+	waitersFlag := fs.String("waiters", "1-10", "диапазон количества официантов")
+	scenario := addScenarioFlags(fs, false)
+	runs := fs.Int("runs", 10, "смен на каждый состав")
+	workers := fs.Int("workers", runtime.NumCPU(), "сколько смен считать параллельно")
+	slaMin := fs.Int("sla", 60, "SLA: p90 времени от посадки до подачи, минут")
+	var model CostModel
+	fs.Float64Var(&model.ChefWage, "chef-wage", 60, "зарплата повара, руб. в час")
+	fs.Float64Var(&model.WaiterWage, "waiter-wage", 40, "зарплата официанта, руб. в час")
+	fs.Float64Var(&model.FoodCost, "food-cost", 0.3, "доля себестоимости продуктов в выручке")
+	fs.Float64Var(&model.WalkoutPenalty, "walkout-penalty", 20, "штраф за компанию, ушедшую ни с чем, руб.")
+	fs.Parse(args)
+
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(2)
+	}
+	chefsFrom, chefsTo, err := parseRange(*chefsFlag)
+	if err != nil {
+		fail(err)
+	}
+	waitersFrom, waitersTo, err := parseRange(*waitersFlag)
+	if err != nil {
+		fail(err)
+	}
+	if chefsFrom < 1 || chefsTo > 10 || waitersFrom < 1 || waitersTo > 15 {
+		fail(fmt.Errorf("поваров должно быть от 1 до 10, официантов — от 1 до 15"))
+	}
+	if *runs < 1 || *workers < 1 {
+		fail(fmt.Errorf("нужна хотя бы одна смена и один поток"))
+	}
+	base, err := scenario.config()
+	exitOnScenarioError(err)
+	if len(base.Roster) > 0 {
+		fail(fmt.Errorf("optimize сам подбирает численность персонала — уберите roster из сценария"))
+	}
+
+	sla := time.Duration(*slaMin) * time.Minute
+	optimize(base, model, chefsFrom, chefsTo, waitersFrom, waitersTo, *runs, base.Seed, *workers, sla)
+}
+
+// optimize перебирает сетку составов с теми же ограничениями, что и при
+// ручном вводе: официантов не меньше поваров, столов не меньше официантов
+func optimize(base SimConfig, model CostModel, chefsFrom, chefsTo, waitersFrom, waitersTo, runs int, seed int64, workers int, sla time.Duration) {
+	fmt.Printf("Подбор персонала: %d столов, политика %s, терпение %s, %d смен на состав (seed %d..%d), SLA p90 <= %s\n",
+		base.Tables, base.Policy.Name(), base.Patience, runs, seed, seed+int64(runs)-1, formatDuration(sla))
+
+	separator := "+--------+-------+--------------------+------------------+-----------------+-----------------+-----+"
+	fmt.Println(separator)
+	fmt.Printf("| %-6s | %-5s | %-18s | %-16s | %-15s | %-15s | %-3s |\n",
+		"Повара", "Офиц.", "Прибыль, руб.", "Выручка, руб.", "Отказы, %", "p90 обсл., мин", "SLA")
+	fmt.Println(separator)
+
+	var results []staffingResult
+	for chefs := chefsFrom; chefs <= chefsTo; chefs++ {
+		for waiters := waitersFrom; waiters <= waitersTo; waiters++ {
+			if waiters < chefs || waiters > base.Tables {
+				continue
+			}
+			cfg := base
+			cfg.Chefs = chefs
+			cfg.Waiters = waiters
+			res := evaluateStaffing(cfg, model, runs, seed, workers, sla)
+			results = append(results, res)
+
+			ok := "нет"
+			if res.Feasible {
+				ok = "да"
+			}
+			fmt.Printf("| %-6d | %-5d | %18s | %16s | %15s | %15s | %-3s |\n",
+				chefs, waiters, res.Profit, res.Revenue, res.LostPct, res.P90Min, ok)
+		}
+	}
+	fmt.Println(separator)
+	fmt.Println("Прибыль = выручка × (1 - доля продуктов) - зарплаты - штраф за отказы; ± — 95% доверительный интервал")
+
+	best := -1
+	for i, res := range results {
+		if res.Feasible && (best < 0 || res.Profit.Mean > results[best].Profit.Mean) {
+			best = i
+		}
+	}
+	if best < 0 {
+		fmt.Println("Ни один состав не укладывается в SLA — увеличьте диапазоны или ослабьте SLA")
+		return
+	}
+	top := results[best]
+	fmt.Printf("\nЛучший состав: %d поваров, %d официантов — прибыль %s руб., p90 обслуживания %s мин\n",
+		top.Chefs, top.Waiters, top.Profit, top.P90Min)
+
+	// составы, чей интервал прибыли пересекается с интервалом лучшего
+	var similar []string
+	for i, res := range results {
+		if i != best && res.Feasible && res.Profit.Mean+res.Profit.Half >= top.Profit.Mean-top.Profit.Half {
+			similar = append(similar, fmt.Sprintf("%d/%d", res.Chefs, res.Waiters))
+		}
+	}
+	if len(similar) > 0 {
+		fmt.Printf("В пределах погрешности от него (повара/официанты): %s\n", strings.Join(similar, ", "))
+	}
+}
@@ -0,0 +1,631 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// === Печать статистики ===
+
+func formatTime(t time.Time) string {
+	return t.Format("15:04")
+}
+
+// formatMinutes печатает длительность, заданную в минутах, как formatDuration
+func formatMinutes(m float64) string {
+	return formatDuration(time.Duration(m * float64(time.Minute)))
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func (s *StatsSnapshot) printTableStats() {
+	var totalOrders, totalTurns, totalAbandoned int
+	var totalRevenue, totalLost float64
+	var totalTime, totalOccupied time.Duration
+
+	openDuration := s.run.Close.Sub(s.run.Open)
+
+	keys := make([]int, 0, len(s.tableStats))
+	for k := range s.tableStats {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	fmt.Println("+------+------+----------------+-------------------+-----------------+----------+----------+----------------+-------+--------------+")
+	fmt.Printf("| %-4s | %-4s | %-14s | %-17s | %-15s | %-8s | %-8s | %-14s | %-5s | %-12s |\n",
+		"Стол", "Мест", "Кол-во заказов", "Общая выручка", "Ср. время обсл.", "Оборотов", "Загрузка", "Ср. ожидание",
+		"Ушли", "Потери")
+	fmt.Println("+------+------+----------------+-------------------+-----------------+----------+----------+----------------+-------+--------------+")
+
+	for _, tableID := range keys {
+		stats := s.tableStats[tableID]
+		avgTime := time.Duration(0)
+		if stats.OrdersCount > 0 {
+			avgTime = stats.TotalTime / time.Duration(stats.OrdersCount)
+		}
+		avgWait := time.Duration(0)
+		if stats.Turns > 0 {
+			avgWait = stats.WaitToSeat / time.Duration(stats.Turns)
+		}
+		occupancy := 100 * stats.OccupiedTime.Seconds() / openDuration.Seconds()
+
+		totalOrders += stats.OrdersCount
+		totalRevenue += stats.TotalRevenue
+		totalTime += stats.TotalTime
+		totalTurns += stats.Turns
+		totalOccupied += stats.OccupiedTime
+		totalAbandoned += stats.Abandoned
+		totalLost += stats.LostRevenue
+
+		fmt.Printf("| %-4d | %-4d | %-14d | %-17.2f | %-15s | %-8d | %7.1f%% | %-14s | %-5d | %-12.2f |\n",
+			tableID, stats.Capacity, stats.OrdersCount, stats.TotalRevenue, formatDuration(avgTime),
+			stats.Turns, occupancy, formatDuration(avgWait), stats.Abandoned, stats.LostRevenue)
+	}
+
+	fmt.Println("+------+------+----------------+-------------------+-----------------+----------+----------+----------------+-------+--------------+")
+	avgTotalTime := time.Duration(0)
+	if totalOrders > 0 {
+		avgTotalTime = totalTime / time.Duration(totalOrders)
+	}
+	avgWait := time.Duration(0)
+	if s.seating.PartiesSeated > 0 {
+		avgWait = s.seating.TotalWait / time.Duration(s.seating.PartiesSeated)
+	}
+	occupancy := 0.0
+	if len(s.run.Tables) > 0 {
+		occupancy = 100 * totalOccupied.Seconds() / (openDuration.Seconds() * float64(len(s.run.Tables)))
+	}
+	fmt.Printf("| ИТОГО|      | %-14d | %-17.2f | %-15s | %-8d | %7.1f%% | %-14s | %-5d | %-12.2f |\n",
+		totalOrders, totalRevenue, formatDuration(avgTotalTime), totalTurns, occupancy, formatDuration(avgWait),
+		totalAbandoned, totalLost)
+	fmt.Println("+------+------+----------------+-------------------+-----------------+----------+----------+----------------+-------+--------------+")
+
+	seat := s.seating
+	fmt.Printf("Компаний пришло: %d (%d гостей), посажено: %d (%d гостей), не пустили: %d, макс. очередь: %d\n",
+		seat.PartiesArrived, seat.GuestsArrived, seat.PartiesSeated, seat.GuestsSeated, seat.PartiesTurnedAway, seat.MaxQueue)
+	abandonRate := 0.0
+	if seat.PartiesArrived > 0 {
+		abandonRate = 100 * float64(seat.PartiesWalkedOut+seat.OrdersAbandoned) / float64(seat.PartiesArrived)
+	}
+	fmt.Printf("Не дождались стола: %d, ушли без заказа: %d, доля отказов: %.1f%%, потерянная выручка: %.2f руб.\n",
+		seat.PartiesWalkedOut, seat.OrdersAbandoned, abandonRate, seat.LostRevenue)
+
+	sv := s.serve
+	avgServe, avgVIP, avgRegular := time.Duration(0), time.Duration(0), time.Duration(0)
+	if sv.Count > 0 {
+		avgServe = sv.Total / time.Duration(sv.Count)
+	}
+	if sv.VIPCount > 0 {
+		avgVIP = sv.VIPTotal / time.Duration(sv.VIPCount)
+	}
+	if sv.Count > sv.VIPCount {
+		avgRegular = (sv.Total - sv.VIPTotal) / time.Duration(sv.Count-sv.VIPCount)
+	}
+	fmt.Printf("Политика кухни: %s, время обслуживания: ср. %s, макс. %s, VIP-столы: ср. %s, остальные: ср. %s\n",
+		s.run.Policy, formatDuration(avgServe), formatDuration(sv.Max), formatDuration(avgVIP), formatDuration(avgRegular))
+}
+
+// === Перцентили и гистограммы ===
+
+const (
+	histogramBucket  = 10 * time.Minute
+	histogramBuckets = 12 // в последний столбец попадает всё, что дольше
+)
+
+// percentile — значение по методу ближайшего ранга; sorted отсортирован
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func sortDurations(xs []time.Duration) []time.Duration {
+	sorted := append([]time.Duration(nil), xs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func histogram(xs []time.Duration) []int {
+	counts := make([]int, histogramBuckets)
+	for _, x := range xs {
+		i := int(x / histogramBucket)
+		if i >= histogramBuckets {
+			i = histogramBuckets - 1
+		}
+		counts[i]++
+	}
+	return counts
+}
+
+// sparkline рисует гистограмму в одну строку
+func sparkline(xs []time.Duration) string {
+	bars := []rune(" ▁▂▃▄▅▆▇█")
+	counts := histogram(xs)
+	maxCount := 0
+	for _, c := range counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+	line := make([]rune, len(counts))
+	for i, c := range counts {
+		line[i] = bars[0]
+		if maxCount > 0 && c > 0 {
+			line[i] = bars[1+(c*(len(bars)-2))/maxCount]
+		}
+	}
+	return string(line)
+}
+
+func printHistogram(title string, xs []time.Duration) {
+	const width = 40
+	counts := histogram(xs)
+	maxCount := 0
+	for _, c := range counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+
+	fmt.Printf("\n%s:\n", title)
+	for i, c := range counts {
+		from := time.Duration(i) * histogramBucket
+		label := fmt.Sprintf("%s-%s", formatDuration(from), formatDuration(from+histogramBucket))
+		if i == histogramBuckets-1 {
+			label = formatDuration(from) + "+"
+		}
+		bar := 0
+		if maxCount > 0 {
+			bar = c * width / maxCount
+		}
+		fmt.Printf("  %-11s | %-*s %d\n", label, width, strings.Repeat("█", bar), c)
+	}
+}
+
+func printLatencyRow(label, metric string, xs []time.Duration) {
+	sorted := sortDurations(xs)
+	longest := time.Duration(0)
+	if len(sorted) > 0 {
+		longest = sorted[len(sorted)-1]
+	}
+	fmt.Printf("| %-16s | %-14s | %-5d | %-5s | %-5s | %-5s | %-5s | %-12s |\n",
+		label, metric, len(xs), formatDuration(percentile(sorted, 50)), formatDuration(percentile(sorted, 90)),
+		formatDuration(percentile(sorted, 99)), formatDuration(longest), sparkline(xs))
+}
+
+// printLatencyStats печатает перцентили задержек по всем заказам, а в
+// подробном режиме — ещё и по каждому столу и блюду
+func (s *StatsSnapshot) printLatencyStats(detailed bool) {
+	var waitToOrder, kitchen, delivery, total []time.Duration
+	byTable := make(map[int][]time.Duration)
+	byDish := make(map[string][]time.Duration)
+	for _, o := range s.orders {
+		waitToOrder = append(waitToOrder, o.WaitToOrder())
+		kitchen = append(kitchen, o.KitchenTime())
+		delivery = append(delivery, o.DeliveryTime())
+		total = append(total, o.TotalTime())
+		byTable[o.TableID] = append(byTable[o.TableID], o.TotalTime())
+		seen := make(map[string]bool)
+		for _, d := range o.Dishes {
+			if !seen[d] {
+				seen[d] = true
+				byDish[d] = append(byDish[d], o.TotalTime())
+			}
+		}
+	}
+
+	separator := "+------------------+----------------+-------+-------+-------+-------+-------+--------------+"
+	fmt.Println("\n=== Время обслуживания: перцентили ===")
+	fmt.Println(separator)
+	fmt.Printf("| %-16s | %-14s | %-5s | %-5s | %-5s | %-5s | %-5s | %-12s |\n",
+		"Срез", "Этап", "N", "p50", "p90", "p99", "Макс", "Гистограмма")
+	fmt.Println(separator)
+	printLatencyRow("Все заказы", "до заказа", waitToOrder)
+	printLatencyRow("Все заказы", "кухня", kitchen)
+	printLatencyRow("Все заказы", "подача", delivery)
+	printLatencyRow("Все заказы", "всего", total)
+
+	if detailed {
+		fmt.Println(separator)
+		tables := make([]int, 0, len(byTable))
+		for t := range byTable {
+			tables = append(tables, t)
+		}
+		sort.Ints(tables)
+		for _, t := range tables {
+			printLatencyRow(fmt.Sprintf("Стол %d", t), "всего", byTable[t])
+		}
+
+		fmt.Println(separator)
+		for _, d := range s.run.Menu {
+			if len(byDish[d.Name]) == 0 {
+				continue
+			}
+			printLatencyRow(d.Name, "всего", byDish[d.Name])
+			printLatencyRow(d.Name, "готовка", s.dishLatency[d.Name])
+		}
+	}
+	fmt.Println(separator)
+
+	printHistogram("Гистограмма полного времени обслуживания (от посадки до подачи)", total)
+}
+
+var phaseTitles = map[string]string{"before": "до", "during": "во время", "after": "после"}
+
+func (s *StatsSnapshot) printIncidentStats() {
+	if len(s.run.Incidents) == 0 {
+		return
+	}
+	fmt.Println("\n=== Происшествия ===")
+	separator := "+----------+-------------+--------+---------+--------+-----------+-----------+-----------+------------+"
+	for _, inc := range s.incidentReports() {
+		if inc.Started == nil {
+			fmt.Printf("\n#%d %s — не наступило\n", inc.ID, inc.Title)
+			continue
+		}
+		until := "конца дня"
+		if inc.Ended != nil {
+			until = formatTime(*inc.Ended)
+		}
+		fmt.Printf("\n#%d %s (%s — %s)\n", inc.ID, inc.Title, formatTime(*inc.Started), until)
+		fmt.Println(separator)
+		fmt.Printf("| %-8s | %-11s | %-6s | %-7s | %-6s | %-9s | %-9s | %-9s | %-10s |\n",
+			"Отрезок", "Время", "Гостей", "Потери", "Подано", "Заказов/ч", "Обсл. p50", "Обсл. p90", "Кухня p90")
+		fmt.Println(separator)
+		for _, ph := range inc.Phases {
+			fmt.Printf("| %-8s | %-11s | %-6d | %6.1f%% | %-6d | %9.1f | %-9s | %-9s | %-10s |\n",
+				phaseTitles[ph.Phase], formatTime(ph.From)+"-"+formatTime(ph.To), ph.PartiesArrived, ph.LostPct,
+				ph.OrdersServed, ph.OrdersPerHour, formatMinutes(ph.P50TotalMin), formatMinutes(ph.P90TotalMin),
+				formatMinutes(ph.P90KitchenMin))
+		}
+		fmt.Println(separator)
+	}
+}
+
+func (s *StatsSnapshot) printHourlyStats() {
+	hours := make([]int, 0, len(s.hourStats))
+	for h := range s.hourStats {
+		hours = append(hours, h)
+	}
+	sort.Ints(hours)
+
+	fmt.Println("\n=== Отказы по часам ===")
+	fmt.Println("+-------+----------+------------+------------+----------+-------------------+-------------------+")
+	fmt.Printf("| %-5s | %-8s | %-10s | %-10s | %-8s | %-17s | %-17s |\n",
+		"Час", "Компаний", "Без стола", "Без заказа", "Отказы", "Выручка", "Потери")
+	fmt.Println("+-------+----------+------------+------------+----------+-------------------+-------------------+")
+
+	for _, h := range hours {
+		stats := s.hourStats[h]
+		rate := 0.0
+		if stats.PartiesArrived > 0 {
+			rate = 100 * float64(stats.WalkedOut+stats.Abandoned) / float64(stats.PartiesArrived)
+		}
+		fmt.Printf("| %02d:00 | %-8d | %-10d | %-10d | %7.1f%% | %-17.2f | %-17.2f |\n",
+			h, stats.PartiesArrived, stats.WalkedOut, stats.Abandoned, rate, stats.Revenue, stats.LostRevenue)
+	}
+	fmt.Println("+-------+----------+------------+------------+----------+-------------------+-------------------+")
+}
+
+var roleTitles = map[string]string{"chef": "повара", "waiter": "официанты"}
+
+func (s *StatsSnapshot) printShiftStats() {
+	fmt.Println("\n=== Смены ===")
+	separator := "+------------+-----------+-------------+------+--------+-------+-----------+-------------+----------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-10s | %-9s | %-11s | %-4s | %-6s | %-5s | %-9s | %-11s | %-8s |\n",
+		"Смена", "Роль", "Время", "Чел.", "Заказы", "Блюда", "Выручка", "Руб./чел.-ч", "Перерывы")
+	fmt.Println(separator)
+	for _, sh := range s.shiftReports() {
+		fmt.Printf("| %-10s | %-9s | %-11s | %-4d | %-6d | %-5d | %-9.2f | %-11.2f | %-8d |\n",
+			sh.Shift, roleTitles[sh.Role], formatTime(sh.Start)+"-"+formatTime(sh.End), sh.Staff,
+			sh.Orders, sh.Dishes, sh.Revenue, sh.RevenuePerStaffHour, sh.Breaks)
+	}
+	fmt.Println(separator)
+
+	gaps := s.coverageGaps()
+	if len(gaps) == 0 {
+		fmt.Println("Провалов покрытия нет: повара и официанты были на месте весь день")
+		return
+	}
+	fmt.Println("Провалы покрытия (на месте никого из роли):")
+	for _, g := range gaps {
+		fmt.Printf("  %s: %s-%s (%s)\n", roleTitles[g.Role], formatTime(g.From), formatTime(g.To),
+			formatDuration(g.To.Sub(g.From)))
+	}
+}
+
+func (s *StatsSnapshot) printStaffStats() {
+	reports := s.staffReports()
+
+	fmt.Println("\n=== Повара ===")
+	separator := "+------+------------+-------+-----------+-----------+-----------+----------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-4s | %-10s | %-5s | %-9s | %-9s | %-9s | %-8s |\n",
+		"ID", "Смена", "Блюда", "На смене", "Готовил", "Простой", "Загрузка")
+	fmt.Println(separator)
+	for _, st := range reports {
+		if st.Role != "chef" {
+			continue
+		}
+		fmt.Printf("| %-4d | %-10s | %-5d | %-9s | %-9s | %-9s | %7.1f%% |\n",
+			st.ID, st.Shift, st.Dishes, formatMinutes(st.DutyMin), formatMinutes(st.BusyMin),
+			formatMinutes(st.IdleMin), st.UtilizationPct)
+	}
+	fmt.Println(separator)
+
+	fmt.Println("\n=== Официанты ===")
+	balance := s.balanceReport(reports)
+	pool := balance.Assignment == "pool"
+	separator = "+------+------------+---------+--------+-------+----------+------+--------------+--------------+-----------+----------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-4s | %-10s | %-7s | %-6s | %-5s | %-8s | %-4s | %-12s | %-12s | %-9s | %-8s |\n",
+		"ID", "Смена", "Приняли", "Подали", "Столы", "Компании", "Пик", "Ср. подача", "p90 подачи", "Простой", "Загрузка")
+	fmt.Println(separator)
+	for _, st := range reports {
+		if st.Role != "waiter" {
+			continue
+		}
+		avgDelivery, p90Delivery := "-", "-"
+		if st.Deliveries > 0 {
+			avgDelivery, p90Delivery = formatMinutes(st.AvgDeliveryMin), formatMinutes(st.P90DeliveryMin)
+		}
+		parties, peak := "-", "-"
+		if !pool {
+			parties, peak = strconv.Itoa(st.Parties), strconv.Itoa(st.PeakTables)
+		}
+		fmt.Printf("| %-4d | %-10s | %-7d | %-6d | %-5d | %-8s | %-4s | %-12s | %-12s | %-9s | %7.1f%% |\n",
+			st.ID, st.Shift, st.Taken, st.Deliveries, st.Tables, parties, peak, avgDelivery, p90Delivery,
+			formatMinutes(st.IdleMin), st.UtilizationPct)
+	}
+	fmt.Println(separator)
+
+	limit := "без ограничения числа столов"
+	if balance.MaxTables > 0 {
+		limit = fmt.Sprintf("не больше %d столов на официанта", balance.MaxTables)
+	}
+	fmt.Printf("Закрепление столов: %s, %s\n", balance.Assignment, limit)
+	fmt.Printf("Загрузка официантов от %.1f%% до %.1f%%, разброс %.1f%%; разброс заказов на час смены %.1f%%\n",
+		balance.MinUtilizationPct, balance.MaxUtilizationPct, balance.UtilizationCVPct, balance.OrdersPerHourCVPct)
+	if balance.HandedOver > 0 {
+		fmt.Printf("Столов передано коллегам при уходе: %d\n", balance.HandedOver)
+	}
+}
+
+func (s *StatsSnapshot) printCourseStats() {
+	reports := s.courseReports()
+	if len(reports) == 0 {
+		return
+	}
+
+	fmt.Println("\n=== Подача по курсам ===")
+	separator := "+------------+-------+----------+------------+------------+------------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-10s | %-5s | %-8s | %-10s | %-10s | %-10s |\n",
+		"Курс", "Подач", "Ели", "Пауза p50", "Пауза p90", "Пауза макс")
+	fmt.Println(separator)
+	for _, c := range reports {
+		p50, p90, longest := "-", "-", "-"
+		if len(s.courseStats[c.Course].Gaps) > 0 {
+			p50, p90, longest = formatMinutes(c.P50GapMin), formatMinutes(c.P90GapMin), formatMinutes(c.MaxGapMin)
+		}
+		fmt.Printf("| %-10s | %-5d | %-8s | %-10s | %-10s | %-10s |\n",
+			courseTitles[c.Course], c.Served, formatMinutes(c.AvgEatMin), p50, p90, longest)
+	}
+	fmt.Println(separator)
+	fmt.Printf("Гости проводят за столом в среднем %s (от посадки до ухода)\n", formatDuration(mean(s.dwell)))
+}
+
+func (s *StatsSnapshot) printReservationStats() {
+	if len(s.bookings) == 0 {
+		return
+	}
+	res := s.reservationReport()
+
+	fmt.Println("\n=== Бронирование ===")
+	fmt.Printf("Броней принято: %d, отказано: %d (нет свободного стола в книге)\n", res.Booked, res.Declined)
+	fmt.Printf("Пришли: %d, опоздали: %d (в среднем на %s), не пришли: %d (%.1f%%), стол отпустили без опоздавших: %d\n",
+		res.Arrived, res.LateArrivals, formatMinutes(res.AvgLateMin), res.NoShows, res.NoShowPct, res.ReleasedLate)
+	fmt.Printf("Посажено по брони: %d — заполнение %.1f%%, ждали стол в среднем %s; не дождались: %d\n",
+		res.Seated, res.FillRatePct, formatMinutes(res.AvgSeatWaitMin), res.WalkedOut)
+	fmt.Printf("Конфликты: %d раз к приходу гостей их стол был ещё занят или его некому было обслужить\n", res.Conflicts)
+	fmt.Printf("Столы простаивали под бронью %s\n", formatMinutes(res.HeldIdleMin))
+	fmt.Printf("Гости с улицы: пришли %d, посажено %d, ждали в среднем %s, потеряно %.1f%%; доля брони среди посадок %.1f%%\n",
+		res.WalkInsArrived, res.WalkInsSeated, formatMinutes(res.WalkInAvgWaitMin), res.WalkInsLostPct, res.ReservedSharePct)
+}
+
+func (s *StatsSnapshot) printOffsiteStats() {
+	reports := s.offsiteReports()
+	if len(reports) == 0 {
+		return
+	}
+
+	fmt.Println("\n=== Вынос и доставка ===")
+	separator := "+----------+--------+----------+--------+-----------+---------+----------+----------+------------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-8s | %-6s | %-8s | %-6s | %-9s | %-7s | %-8s | %-8s | %-10s |\n",
+		"Канал", "Заказы", "Отказано", "Отдано", "Выручка", "Вовремя", "Ср. срок", "p90 срок", "Опоздание")
+	fmt.Println(separator)
+	for _, o := range reports {
+		fmt.Printf("| %-8s | %-6d | %-8d | %-6d | %-9.2f | %6.1f%% | %-8s | %-8s | %-10s |\n",
+			channelTitles[o.Channel], o.Orders, o.Refused, o.Handed, o.Revenue, o.OnTimePct,
+			formatMinutes(o.AvgLeadMin), formatMinutes(o.P90LeadMin), formatMinutes(o.AvgLateMin))
+	}
+	fmt.Println(separator)
+
+	zones := s.zoneReports()
+	if len(zones) == 0 {
+		return
+	}
+	fmt.Println("Доставка по районам:")
+	for _, z := range zones {
+		fmt.Printf("  %s: %d доставок, вовремя %.1f%%, в пути ср. %s, p90 %s\n", z.Zone, z.Deliveries,
+			z.OnTimePct, formatMinutes(z.AvgTravelMin), formatMinutes(z.P90TravelMin))
+	}
+	fmt.Println("Курьеры:")
+	for _, c := range s.courierReports() {
+		fmt.Printf("  курьер %d: %d доставок, в дороге %s (%.1f%% дня)\n", c.ID, c.Deliveries,
+			formatMinutes(c.BusyMin), c.UtilizationPct)
+	}
+}
+
+func (s *StatsSnapshot) printInventoryStats() {
+	if len(s.run.Ingredients) == 0 {
+		return
+	}
+
+	fmt.Println("\n=== Склад ===")
+	separator := "+------------+------+--------+--------+---------+----------+-------+------------+-----------+--------------+"
+	fmt.Println(separator)
+	fmt.Printf("| %-10s | %-4s | %-6s | %-6s | %-7s | %-8s | %-5s | %-10s | %-9s | %-12s |\n",
+		"Продукт", "Ед.", "Было", "Расход", "Впустую", "Осталось", "Нет с", "Не хватило", "К закупке", "Расход, руб.")
+	fmt.Println(separator)
+	var usedCost, wasteCost float64
+	for _, ing := range s.inventoryReports() {
+		outAt := "-"
+		if ing.OutAt != nil {
+			outAt = formatTime(*ing.OutAt)
+		}
+		fmt.Printf("| %-10s | %-4s | %6.2f | %6.2f | %7.2f | %8.2f | %-5s | %10.2f | %9.2f | %12.2f |\n",
+			ing.Ingredient, ing.Unit, ing.Opening, ing.Used, ing.Wasted, ing.Closing, outAt, ing.Shortage,
+			ing.Purchase, ing.UsedCost)
+		usedCost += ing.UsedCost
+		wasteCost += ing.Wasted * ing.Cost
+	}
+	fmt.Println(separator)
+
+	fmt.Println("+----------+--------+-------------+-----------+---------+--------------+")
+	fmt.Printf("| %-8s | %-6s | %-11s | %-9s | %-7s | %-12s |\n",
+		"Блюдо", "Цена", "Себестоим.", "Food cost", "Отказов", "Стоп-лист с")
+	fmt.Println("+----------+--------+-------------+-----------+---------+--------------+")
+	for _, d := range s.run.Menu {
+		cost := s.portionCost(d.Name)
+		soldOut := "-"
+		if t, ok := s.soldOutAt[d.Name]; ok {
+			soldOut = formatTime(t)
+		}
+		fmt.Printf("| %-8s | %6.2f | %11.2f | %8.1f%% | %-7d | %-12s |\n",
+			d.Name, d.BasePrice, cost, percentOf(cost, d.BasePrice), s.refused[d.Name], soldOut)
+	}
+	fmt.Println("+----------+--------+-------------+-----------+---------+--------------+")
+	fmt.Printf("Расход продуктов: %.2f руб., из них впустую: %.2f руб. (%.1f%% к продажам по меню). "+
+		"Ушли из-за стоп-листа: %d компаний\n",
+		usedCost, wasteCost, percentOf(usedCost, s.billing.Gross), s.seating.LeftSoldOut)
+}
+
+var promoTitles = map[string]string{"happy_hour": "счастливый час"}
+
+var paymentTitles = map[string]string{"card": "картой", "cash": "наличными"}
+
+func (s *StatsSnapshot) printBillingStats() {
+	b := s.billingReport()
+	separator := "+------------------------------+------------------+"
+	row := func(label string, amount float64) {
+		fmt.Printf("| %-28s | %16.2f |\n", label, amount)
+	}
+
+	fmt.Println("\n=== Касса ===")
+	fmt.Println(separator)
+	fmt.Printf("| %-28s | %-16s |\n", "Статья", "Сумма (руб.)")
+	fmt.Println(separator)
+	row("Продажи по меню", b.GrossSales)
+	row("Скидки", -b.Discounts)
+	for _, p := range b.Promotions {
+		title, ok := promoTitles[p.Promo]
+		if !ok {
+			title = p.Promo
+		}
+		row("  "+title, -p.Discount)
+	}
+	row("Сервисный сбор", b.Service)
+	row("Оплачено гостями", b.Total)
+	row("  в т.ч. НДС", b.VAT)
+	row("Чистая выручка (без НДС)", b.NetRevenue)
+	row("Чаевые", b.Tips)
+	fmt.Println(separator)
+	for _, p := range b.Payments {
+		row(fmt.Sprintf("Оплата %s (%d)", paymentTitles[p.Method], p.Count), p.Amount+p.Tips)
+	}
+	fmt.Println(separator)
+	fmt.Printf("Счетов: %d, из них раздельных: %d. Средний чек: %.2f руб., чаевые в среднем %.1f%%\n",
+		b.Checks, b.SplitChecks, b.AvgCheck, b.AvgTipPct)
+}
+
+func (s *StatsSnapshot) printStationStats() {
+	openDuration := s.run.Close.Sub(s.run.Open)
+
+	fmt.Println("\n=== Загрузка кухни ===")
+	fmt.Println("+----------------+-------+-------+----------------+-----------+----------+")
+	fmt.Printf("| %-14s | %-5s | %-5s | %-14s | %-9s | %-8s |\n",
+		"Станция", "Мест", "Блюд", "Ср. ожидание", "Макс. оч.", "Загрузка")
+	fmt.Println("+----------------+-------+-------+----------------+-----------+----------+")
+
+	for _, st := range s.run.Stations {
+		stats := s.stationStats[st.Name]
+		avgQueue := time.Duration(0)
+		if stats.Dishes > 0 {
+			avgQueue = stats.QueueTime / time.Duration(stats.Dishes)
+		}
+		utilization := 100 * stats.BusyTime.Seconds() / (openDuration.Seconds() * float64(stats.Capacity))
+		fmt.Printf("| %-14s | %-5d | %-5d | %-14s | %-9d | %7.1f%% |\n",
+			st.Name, stats.Capacity, stats.Dishes, formatDuration(avgQueue), stats.MaxQueue, utilization)
+	}
+	fmt.Println("+----------------+-------+-------+----------------+-----------+----------+")
+}
+
+func (s *StatsSnapshot) printDishStats() {
+	var totalPortions int
+	var totalRevenue float64
+
+	fmt.Println("\n=== Статистика по блюдам ===")
+	fmt.Println("+----------+------------------+------------------+")
+	fmt.Printf("| %-8s | %-16s | %-16s |\n", "Блюдо", "Количество порций", "Выручка (руб.)")
+	fmt.Println("+----------+------------------+------------------+")
+
+	for _, dish := range s.run.Menu {
+		data, ok := s.dishStats[dish.Name]
+		if !ok {
+			continue
+		}
+		totalPortions += data.Portions
+		totalRevenue += data.Revenue
+		fmt.Printf("| %-8s | %-16d | %-16.2f |\n", dish.Name, data.Portions, data.Revenue)
+	}
+
+	fmt.Println("+----------+------------------+------------------+")
+	fmt.Printf("| ИТОГО    | %-16d | %-16.2f |\n", totalPortions, totalRevenue)
+	fmt.Println("+----------+------------------+------------------+")
+}
+
+// printAll печатает все разделы статистики; в итоге смены (final) задержки
+// расписаны ещё и по столам и блюдам
+func (s *StatsSnapshot) printAll(final bool) {
+	if !s.interrupted.IsZero() {
+		fmt.Printf("Смена прервана в %s: новых гостей и заказов не было, зал и кухня доработали начатое\n",
+			formatTime(s.interrupted))
+	}
+	s.printTableStats()
+	s.printReservationStats()
+	s.printDishStats()
+	s.printCourseStats()
+	s.printOffsiteStats()
+	s.printStaffStats()
+	s.printBillingStats()
+	s.printInventoryStats()
+	s.printStationStats()
+	s.printShiftStats()
+	s.printIncidentStats()
+	s.printHourlyStats()
+	s.printLatencyStats(final)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// === Бронирование ===
+// Книга брони заполняется до открытия: на каждый слот окна приходит
+// случайное число броней, и каждой сразу назначается стол, свободный от
+// других броней на время обеда. Если такого стола нет, бронь не принимают.
+// За hold_before до слота стол придерживают: других гостей за него не
+// сажают. Гости по брони приходят к слоту или опаздывают; если их нет
+// через grace после слота, стол отпускают. Часть гостей не приходит вовсе.
+
+// ReservationModel — правила брони; нулевая частота — только гости с улицы
+type ReservationModel struct {
+	PerHour    float64       // броней на каждый час окна
+	From, To   time.Duration // окно слотов от полуночи, To не включается
+	Slot       time.Duration
+	TableTime  time.Duration // на сколько бронь занимает стол в книге
+	HoldBefore time.Duration
+	Grace      time.Duration
+	NoShow     float64
+	LateChance float64
+	Late       distribution
+}
+
+// Booking — бронь из книги
+type Booking struct {
+	ID     int
+	Size   int
+	Slot   time.Time
+	Table  *Table
+	NoShow bool
+	Late   time.Duration
+}
+
+// takeBookings заполняет книгу брони на день
+func (r *Restaurant) takeBookings() {
+	m := r.cfg.Reservations
+	day := r.openTime.Add(-r.cfg.OpenAt)
+	id := 0
+	for at := m.From; at < m.To; at += m.Slot {
+		slot := day.Add(at)
+		for n := poisson(r.resRng, m.PerHour*m.Slot.Hours()); n > 0; n-- {
+			id++
+			b := &Booking{
+				ID:     id,
+				Size:   partySizes[r.resRng.Intn(len(partySizes))],
+				Slot:   slot,
+				NoShow: r.resRng.Float64() < m.NoShow,
+			}
+			if r.resRng.Float64() < m.LateChance {
+				b.Late = m.Late.sample(r.resRng)
+			}
+			b.Table = r.bookTable(b.Size, slot)
+			if b.Table == nil {
+				r.logf("Бронь #%d на %s (%d чел.) не принята — все подходящие столы заняты\n",
+					b.ID, formatTime(slot), b.Size)
+				r.emit(Event{Type: evReservationDeclined, Booking: b.ID, Size: b.Size, Slot: &slot})
+				continue
+			}
+			r.logf("Бронь #%d на %s: %d чел., стол %d\n", b.ID, formatTime(slot), b.Size, b.Table.ID)
+			r.emit(Event{Type: evReservationBooked, Booking: b.ID, Size: b.Size, Table: b.Table.ID, Slot: &slot})
+			r.bookings = append(r.bookings, b)
+		}
+	}
+}
+
+// bookTable ищет самый маленький стол, который в книге свободен от других
+// броней с начала удержания до конца обеда
+func (r *Restaurant) bookTable(size int, slot time.Time) *Table {
+	m := r.cfg.Reservations
+	from, to := slot.Add(-m.HoldBefore), slot.Add(m.TableTime)
+	var best *Table
+	for _, t := range r.floor.tables {
+		if t.Capacity < size || (best != nil && t.Capacity >= best.Capacity) {
+			continue
+		}
+		free := true
+		for _, b := range r.bookings {
+			if b.Table == t && b.Slot.Add(-m.HoldBefore).Before(to) && from.Before(b.Slot.Add(m.TableTime)) {
+				free = false
+				break
+			}
+		}
+		if free {
+			best = t
+		}
+	}
+	return best
+}
+
+// reservation придерживает стол под бронь и приводит гостей
+func (r *Restaurant) reservation(b *Booking) {
+	m := r.cfg.Reservations
+	if !r.pause(b.Slot.Add(-m.HoldBefore).Sub(r.clock.now)) || r.floor.closed {
+		// стол ещё не придержан, releaseTable отпускать нечего
+		r.logf("[%s] Бронь #%d снята (%s)\n", formatTime(r.clock.now), b.ID, releaseTitles["closing"])
+		r.emit(Event{Type: evReservationReleased, Booking: b.ID, Table: b.Table.ID, Reason: "closing"})
+		return
+	}
+	b.Table.held = b
+	r.logf("[%s] Стол %d придержан под бронь #%d на %s\n", formatTime(r.clock.now), b.Table.ID, b.ID, formatTime(b.Slot))
+	r.emit(Event{Type: evReservationHeld, Booking: b.ID, Table: b.Table.ID})
+
+	arrive, deadline := b.Slot.Add(b.Late), b.Slot.Add(m.Grace)
+	if b.NoShow || arrive.After(deadline) {
+		ok := r.pause(deadline.Sub(r.clock.now))
+		reason := "late"
+		switch {
+		case !ok:
+			reason = "closing"
+		case b.NoShow:
+			reason = "no_show"
+		}
+		r.releaseTable(b, reason)
+		if b.NoShow || !ok {
+			return
+		}
+	}
+	if !r.pause(arrive.Sub(r.clock.now)) {
+		r.releaseTable(b, "closing")
+		return
+	}
+	r.party(&Party{
+		ID:       int(atomic.AddInt32(&r.partyIDCounter, 1)),
+		Size:     b.Size,
+		Patience: r.patience.sample(r.resRng),
+		Arrived:  r.clock.now,
+		Booking:  b,
+		cond:     simCond{clock: r.clock},
+	})
+}
+
+// releaseTable отпускает стол, придержанный под бронь, и сажает за него ожидающих
+func (r *Restaurant) releaseTable(b *Booking, reason string) {
+	if b.Table.held != b {
+		return
+	}
+	b.Table.held = nil
+	r.logf("[%s] Бронь #%d снята (%s), стол %d свободен для других гостей\n",
+		formatTime(r.clock.now), b.ID, releaseTitles[reason], b.Table.ID)
+	r.emit(Event{Type: evReservationReleased, Booking: b.ID, Table: b.Table.ID, Reason: reason})
+	r.seatWaiting()
+}
+
+var releaseTitles = map[string]string{
+	"no_show":    "гости не пришли",
+	"late":       "гости опаздывают",
+	"walked_out": "гости не дождались стола",
+	"closing":    "смена закрывается",
+}
+
+// poisson — число событий пуассоновского потока со средним mean
+func poisson(rng *rand.Rand, mean float64) int {
+	n := 0
+	for t := rng.ExpFloat64() / mean; t < 1; t += rng.ExpFloat64() / mean {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// === Ресторан ===
+// Меню, заказ, параметры смены и сам ресторан: кто работает и как идёт смена.
+
+type Dish struct {
+	Name        string  `json:"name"`
+	BasePrice   float64 `json:"price"`
+	MinCookTime int     `json:"min_cook_min"`
+	MaxCookTime int     `json:"max_cook_min"`
+	Station     string  `json:"station"`
+	Course      string  `json:"course,omitempty"` // starter, main или dessert; пусто — main
+
+	// продукты на порцию; пустой рецепт — блюдо не зависит от склада
+	Recipe map[string]float64 `json:"recipe,omitempty"`
+}
+
+const (
+	stationGrill  = "Гриль"
+	stationStove  = "Плита"
+	stationCold   = "Холодный цех"
+	stationPastry = "Кондитерская"
+)
+
+var dishes = []Dish{
+	{"Суп", 100.0, 5, 30, stationStove, courseStarter, map[string]float64{"Бульон": 0.3, "Овощи": 0.15}},
+	{"Стейк", 250.0, 10, 25, stationGrill, courseMain, map[string]float64{"Говядина": 0.25, "Овощи": 0.1}},
+	{"Паста", 150.0, 6, 20, stationStove, courseMain, map[string]float64{"Макароны": 0.12, "Сливки": 0.05, "Сыр": 0.03}},
+	{"Салат", 80.0, 3, 15, stationCold, courseStarter, map[string]float64{"Овощи": 0.2, "Сыр": 0.02}},
+	{"Десерт", 90.0, 4, 13, stationPastry, courseDessert, map[string]float64{"Мука": 0.05, "Сливки": 0.05, "Яйца": 1}},
+}
+
+// Курсы подаются по порядку: следующий готовят, когда гости доели предыдущий
+const (
+	courseStarter = "starter"
+	courseMain    = "main"
+	courseDessert = "dessert"
+)
+
+var courses = []string{courseStarter, courseMain, courseDessert}
+
+var courseTitles = map[string]string{courseStarter: "закуски", courseMain: "основное", courseDessert: "десерт"}
+
+func (d Dish) course() string {
+	if d.Course == "" {
+		return courseMain
+	}
+	return d.Course
+}
+
+// CourseModel — кто из гостей берёт закуску и десерт (основное берут все)
+// и сколько едят каждый курс
+type CourseModel struct {
+	StarterChance float64
+	DessertChance float64
+	Eat           map[string]distribution
+}
+
+// Размеры компаний гостей (чаще приходят по двое)
+var partySizes = []int{1, 2, 2, 2, 3, 4, 4, 5, 6}
+
+// Вместимость столов повторяется по кругу: 2, 4, 4, 6, 2, 4, ...
+var tableCapacities = []int{2, 4, 4, 6}
+
+// Каждый vipEvery-й стол — VIP (5, 10, 15, 20)
+const vipEvery = 5
+
+// Длительность этапов обслуживания стола (виртуальное время)
+const (
+	minTakeOrderTime = 2 * time.Minute
+	maxTakeOrderTime = 5 * time.Minute
+	minDeliveryTime  = 1 * time.Minute
+	maxDeliveryTime  = 3 * time.Minute
+	minPayTime       = 3 * time.Minute
+	maxPayTime       = 8 * time.Minute
+	minClearTime     = 3 * time.Minute
+	maxClearTime     = 6 * time.Minute
+
+	lastOrdersBeforeClose = 30 * time.Minute
+
+	// запас к самому долгому блюду заказа при расчёте срока его готовности
+	dueSlack = 10 * time.Minute
+)
+
+// Терпение компании: сколько она готова ждать стол и, отдельно, свой заказ
+var defaultPatience = distribution{Kind: "exp", Mean: 40 * time.Minute}
+
+type Order struct {
+	OrderID   int
+	WaiterID  int
+	TableID   int
+	Dishes    []Dish
+	Price     float64 // по ценам меню
+	SeatedAt  time.Time
+	StartTime time.Time
+	ReadyTime time.Time
+	EndTime   time.Time
+	Due       time.Time
+	VIP       bool
+
+	// заказ навынос или с доставкой: канал, район и обещанный гостю срок
+	Channel  string
+	Promised time.Time
+	zone     *Zone
+	handoff  simCond // будит гостя, который пришёл за заказом навынос раньше готовности
+
+	party     *Party
+	courses   [][]Dish // блюда заказа по курсам
+	course    int      // курс, который сейчас готовят или едят
+	started   int      // блюда курса, которые уже взяли в работу
+	pending   int      // блюда курса, которые ещё готовятся
+	cancelled bool
+}
+
+func (o *Order) courseName() string {
+	return o.courses[o.course][0].course()
+}
+
+// offsite — заказ пришёл не из зала
+func (o *Order) offsite() bool { return o.Channel != "" }
+
+// dest — для кого готовится заказ, для журнала
+func (o *Order) dest() string {
+	switch o.Channel {
+	case channelTakeaway:
+		return "навынос"
+	case channelDelivery:
+		return "в доставку"
+	}
+	return fmt.Sprintf("для стола %d", o.TableID)
+}
+
+type Restaurant struct {
+	stats     *Stats
+	events    *json.Encoder
+	dashboard *Dashboard
+
+	// чем сейчас заняты официанты и повара (индекс — номер минус один)
+	waiterTasks []string
+	chefDishes  []string
+	staff       []*StaffMember
+
+	cfg  SimConfig
+	menu []Dish
+	rng  *rand.Rand // свой генератор у каждой смены, чтобы прогон повторялся по seed
+	// у кассы отдельный генератор, чтобы правила оплаты не меняли ход смены
+	billRng *rand.Rand
+	// и у выноса с доставкой — чтобы их поток не зависел от гостей зала
+	offRng *rand.Rand
+	// и у книги брони
+	resRng *rand.Rand
+	// и у происшествий
+	incRng *rand.Rand
+
+	// счётчики номеров свои у каждой смены, чтобы смены могли идти параллельно
+	orderIDCounter  int32
+	partyIDCounter  int32
+	ticketIDCounter int32
+
+	stock *Inventory // nil — продукты не ограничены
+
+	couriers    *simQueue[*Order] // готовые заказы, ждущие курьера
+	offsiteOpen int               // заказы навынос и с доставкой, которые ещё не отдали
+
+	bookings []*Booking // принятые брони в порядке времени
+
+	waiters    []*StaffMember // официанты по номерам
+	nextWaiter int            // с кого начинать поиск при рассадке по кругу
+
+	incidents []*incidentRun
+	disabled  map[string]bool // блюда, снятые с меню по происшествию
+
+	clock     *simClock
+	floor     *Floor
+	kitchen   *Kitchen
+	openTime  time.Time
+	closeTime time.Time
+	patience  distribution
+
+	// interrupted — смену прервали раньше срока; lastCall будит тех,
+	// кто ждёт наступления времени, чтобы они узнали об этом сразу
+	interrupted bool
+	lastCall    simCond
+}
+
+// SimConfig — параметры одной смены
+type SimConfig struct {
+	Chefs    int
+	Waiters  int
+	Tables   int
+	Menu     []Dish
+	OpenAt   time.Duration // от полуночи
+	Duration time.Duration
+	Arrivals ArrivalModel
+	Roster   []ShiftPlan // пусто — все работают весь день без перерывов
+	Patience distribution
+	Policy   dispatchPolicy
+	Billing  Billing
+
+	Assignment tableAssignment // nil — общий зал
+	MaxTables  int             // столов на официанта одновременно; 0 — без ограничения
+
+	Courses      CourseModel
+	Offsite      OffsiteModel
+	Reservations ReservationModel
+	Incidents    []Incident
+
+	Ingredients   []Ingredient // пусто — продукты не ограничены
+	ReorderChance float64      // вероятность, что гости выберут другое блюдо вместо закончившегося
+	Seed          int64
+	Day           time.Time // день смены; нулевой — сегодня
+	Realtime      bool
+	Quiet         bool // не печатать ход смены (для серий прогонов)
+}
+
+func newRestaurant(cfg SimConfig) *Restaurant {
+	openTime, closeTime := getVirtualOpenCloseTimes(cfg.Day, cfg.OpenAt, cfg.Duration)
+	clock := newSimClock(openTime, cfg.Realtime)
+	if len(cfg.Roster) == 0 {
+		cfg.Roster = []ShiftPlan{
+			{Name: "день", Role: "chef", Count: cfg.Chefs, Start: cfg.OpenAt, End: cfg.OpenAt + cfg.Duration},
+			{Name: "день", Role: "waiter", Count: cfg.Waiters, Start: cfg.OpenAt, End: cfg.OpenAt + cfg.Duration},
+		}
+	}
+	if cfg.Assignment == nil {
+		cfg.Assignment = poolAssignment{}
+	}
+	roster, incidents := planIncidents(cfg, openTime.Add(-cfg.OpenAt))
+	r := &Restaurant{
+		stats:     newStats(),
+		clock:     clock,
+		floor:     newFloor(clock, cfg.Tables),
+		kitchen:   newKitchen(clock, cfg.Policy),
+		openTime:  openTime,
+		closeTime: closeTime,
+		patience:  cfg.Patience,
+		lastCall:  simCond{clock: clock},
+		cfg:       cfg,
+		menu:      cfg.Menu,
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		billRng:   rand.New(rand.NewSource(cfg.Seed ^ 0x5DEECE66D)),
+		offRng:    rand.New(rand.NewSource(cfg.Seed ^ 0x2545F491)),
+		resRng:    rand.New(rand.NewSource(cfg.Seed ^ 0x9E3779B9)),
+		incRng:    rand.New(rand.NewSource(cfg.Seed ^ 0x6A09E667)),
+		couriers:  newSimQueue[*Order](clock),
+		staff:     rosterStaff(roster, openTime),
+		stock:     newInventory(cfg.Ingredients),
+		incidents: incidents,
+		disabled:  make(map[string]bool),
+	}
+	for _, inc := range incidents {
+		if inc.Kind == incidentStaffOut {
+			r.sendHome(inc)
+		}
+	}
+	_, pool := cfg.Assignment.(poolAssignment)
+	for _, m := range r.staff {
+		if m.Role == "chef" {
+			r.chefDishes = append(r.chefDishes, "")
+			continue
+		}
+		r.waiterTasks = append(r.waiterTasks, "")
+		m.tasks = r.floor.tasks
+		if !pool {
+			m.tasks = newSimQueue[floorTask](clock)
+		}
+		r.waiters = append(r.waiters, m)
+	}
+	return r
+}
+
+// start запускает поваров, официантов, поток гостей и хоста;
+// сама смена идёт в run
+func (r *Restaurant) start() {
+	r.emit(Event{Type: evRunStarted, Run: r.runInfo()})
+
+	for _, m := range r.staff {
+		m := m
+		if m.Role == "chef" {
+			r.logf("Повар %d (смена «%s», %s–%s) работает на станциях: %v\n",
+				m.ID, m.Shift, formatTime(m.Start), formatTime(m.End), m.Skills)
+			r.clock.Go(func() { chef(m, r) })
+		} else {
+			r.clock.Go(func() { waiter(m, r) })
+		}
+	}
+	r.clock.Go(func() { simulateCustomers(r, r.cfg.Tables) })
+	if r.cfg.Reservations.PerHour > 0 {
+		r.takeBookings()
+		for _, b := range r.bookings {
+			b := b
+			r.clock.Go(func() { r.reservation(b) })
+		}
+	}
+	if m := r.cfg.Offsite; m.TakeawayRate > 0 {
+		r.clock.Go(func() { offsiteOrders(r, channelTakeaway, m.TakeawayRate) })
+	}
+	if m := r.cfg.Offsite; m.DeliveryRate > 0 {
+		r.clock.Go(func() { offsiteOrders(r, channelDelivery, m.DeliveryRate) })
+		for id := 1; id <= m.Couriers; id++ {
+			id := id
+			r.clock.Go(func() { courier(id, r) })
+		}
+	}
+	for _, inc := range r.incidents {
+		inc := inc
+		r.clock.Go(func() { r.incident(inc) })
+	}
+	r.clock.Go(func() { host(r) })
+}
+
+// simulate проводит смену без вывода и без привязки к реальному времени
+func simulate(cfg SimConfig) *Stats {
+	cfg.Realtime = false
+	cfg.Quiet = true
+	r := newRestaurant(cfg)
+	r.run(context.Background())
+	return r.stats
+}
+
+// run проводит смену от открытия до опустевшего зала. Отмена ctx досрочно
+// объявляет последние заказы: гостей больше не сажают, заказы не принимают,
+// а кухня и зал дорабатывают уже начатое
+func (r *Restaurant) run(ctx context.Context) {
+	r.start()
+	r.clock.Run(ctx, r.interrupt)
+	r.emit(Event{Type: evRunFinished})
+}
+
+// interrupt вызывается часами при отмене смены
+func (r *Restaurant) interrupt() {
+	if r.interrupted {
+		return
+	}
+	r.interrupted = true
+	r.logf("[%s] Смена прервана — последние заказы, кухня доготавливает начатое\n", formatTime(r.clock.now))
+	r.emit(Event{Type: evRunInterrupted})
+	r.lastCall.Broadcast()
+}
+
+// pause ждёт d виртуального времени; false — если смену прервали раньше
+func (r *Restaurant) pause(d time.Duration) bool {
+	if r.interrupted {
+		return false
+	}
+	if d <= 0 {
+		return true
+	}
+	return !r.lastCall.WaitTimeout(d)
+}
+
+func (r *Restaurant) logf(format string, args ...any) {
+	if !r.cfg.Quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+func randDuration(rng *rand.Rand, from, to time.Duration) time.Duration {
+	return from + time.Duration(rng.Int63n(int64(to-from)+1))
+}
+
+// distribution описывает случайную длительность: fixed, uniform (Mean±Spread),
+// exp (экспоненциальное со средним Mean) или normal (Mean, отклонение Spread)
+type distribution struct {
+	Kind   string
+	Mean   time.Duration
+	Spread time.Duration
+}
+
+func parseDistribution(kind string, mean time.Duration) (distribution, error) {
+	switch kind {
+	case "fixed", "exp":
+		return distribution{Kind: kind, Mean: mean}, nil
+	case "uniform":
+		return distribution{Kind: kind, Mean: mean, Spread: mean / 2}, nil
+	case "normal":
+		return distribution{Kind: kind, Mean: mean, Spread: mean / 4}, nil
+	}
+	return distribution{}, fmt.Errorf("неизвестное распределение %q", kind)
+}
+
+func (d distribution) sample(rng *rand.Rand) time.Duration {
+	var v time.Duration
+	switch d.Kind {
+	case "uniform":
+		v = randDuration(rng, d.Mean-d.Spread, d.Mean+d.Spread)
+	case "exp":
+		v = time.Duration(rng.ExpFloat64() * float64(d.Mean))
+	case "normal":
+		v = d.Mean + time.Duration(rng.NormFloat64()*float64(d.Spread))
+	default:
+		v = d.Mean
+	}
+	if v < 0 {
+		v = 0
+	}
+	return v
+}
+
+func (d distribution) String() string {
+	return fmt.Sprintf("%s(%v)", d.Kind, d.Mean)
+}
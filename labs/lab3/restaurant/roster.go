@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// === График смен ===
+// Каждый сотрудник выходит в начале своей смены и уходит в конце, доделав
+// текущее дело. Кто работает до закрытия, остаётся, пока зал и кухня не
+// опустеют. Перерыв обязателен: его берут при первой свободной минуте после
+// break_after от начала смены.
+
+// ShiftPlan — строка графика: count сотрудников одной роли с общим временем
+type ShiftPlan struct {
+	Name        string
+	Role        string // chef или waiter
+	Count       int
+	Start       time.Duration // от полуночи дня смены
+	End         time.Duration
+	BreakAfter  time.Duration // 0 — без перерыва
+	BreakLength time.Duration
+}
+
+// StaffMember — сотрудник из графика
+type StaffMember struct {
+	Role        string        `json:"role"`
+	ID          int           `json:"id"`
+	Shift       string        `json:"shift"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	BreakAfter  time.Duration `json:"break_after,omitempty"`
+	BreakLength time.Duration `json:"break_length,omitempty"`
+	Skills      []string      `json:"skills,omitempty"`
+
+	breakTaken bool
+	present    bool                 // официант на смене и не на перерыве
+	tables     int                  // столы, которые официант ведёт сейчас
+	parties    int                  // компании, закреплённые за ним за смену
+	tasks      *simQueue[floorTask] // дела его столов; в общем зале — общая очередь
+}
+
+// rosterStaff раскладывает график на сотрудников; номера идут подряд внутри
+// роли, навыки поваров подбираются так, чтобы каждая смена закрывала все станции
+func rosterStaff(roster []ShiftPlan, openTime time.Time) []*StaffMember {
+	day := time.Date(openTime.Year(), openTime.Month(), openTime.Day(), 0, 0, 0, 0, openTime.Location())
+	var staff []*StaffMember
+	ids := make(map[string]int)
+	for _, plan := range roster {
+		var skills [][]string
+		if plan.Role == "chef" {
+			skills = chefSkills(plan.Count)
+		}
+		for i := 0; i < plan.Count; i++ {
+			ids[plan.Role]++
+			m := &StaffMember{
+				Role:        plan.Role,
+				ID:          ids[plan.Role],
+				Shift:       plan.Name,
+				Start:       day.Add(plan.Start),
+				End:         day.Add(plan.End),
+				BreakAfter:  plan.BreakAfter,
+				BreakLength: plan.BreakLength,
+			}
+			if skills != nil {
+				m.Skills = skills[i]
+			}
+			staff = append(staff, m)
+		}
+	}
+	return staff
+}
+
+func (m *StaffMember) title() string {
+	if m.Role == "chef" {
+		return fmt.Sprintf("Повар %d", m.ID)
+	}
+	return fmt.Sprintf("Официант %d", m.ID)
+}
+
+func (m *StaffMember) event(typ string) Event {
+	if m.Role == "chef" {
+		return Event{Type: typ, Chef: m.ID}
+	}
+	return Event{Type: typ, Waiter: m.ID}
+}
+
+// leavesBeforeClose — уходит ли сотрудник по графику, не дожидаясь конца работы
+// (после прерывания смены все, кто на месте, остаются до конца)
+func (r *Restaurant) leavesBeforeClose(m *StaffMember) bool {
+	return m.End.Before(r.closeTime) && !r.interrupted
+}
+
+func (r *Restaurant) setStaffStatus(m *StaffMember, status string) {
+	if m.Role == "chef" {
+		r.setChefDish(m.ID, status)
+	} else {
+		r.setWaiterTask(m.ID, status)
+	}
+}
+
+// beginShift ждёт начала смены; false — смену прервали раньше, и выходить уже не нужно
+func (r *Restaurant) beginShift(m *StaffMember) bool {
+	r.setStaffStatus(m, offDuty)
+	if !r.pause(m.Start.Sub(r.clock.now)) {
+		return false
+	}
+	r.setStaffStatus(m, "")
+	r.logf("[%s] %s вышел на смену «%s»\n", formatTime(r.clock.now), m.title(), m.Shift)
+	r.emit(m.event(evShiftStarted))
+	r.setPresent(m, true, "cover")
+	return true
+}
+
+func (r *Restaurant) endShift(m *StaffMember) {
+	r.logf("[%s] %s ушёл со смены «%s»\n", formatTime(r.clock.now), m.title(), m.Shift)
+	r.emit(m.event(evShiftEnded))
+	r.setStaffStatus(m, offDuty)
+	r.setPresent(m, false, "shift_end")
+}
+
+// stayOnDuty вызывается, когда сотрудник свободен: отправляет его на
+// положенный перерыв и отпускает домой по окончании смены
+func (r *Restaurant) stayOnDuty(m *StaffMember) bool {
+	if r.leavesBeforeClose(m) && !r.clock.now.Before(m.End) {
+		r.endShift(m)
+		return false
+	}
+	if m.BreakAfter > 0 && !m.breakTaken && !r.interrupted && !r.clock.now.Before(m.Start.Add(m.BreakAfter)) {
+		m.breakTaken = true
+		r.logf("[%s] %s ушёл на перерыв (%v)\n", formatTime(r.clock.now), m.title(), m.BreakLength)
+		r.emit(m.event(evBreakStarted))
+		r.setStaffStatus(m, onBreak)
+		r.setPresent(m, false, "break")
+		r.clock.Sleep(m.BreakLength)
+		r.setStaffStatus(m, "")
+		r.logf("[%s] %s вернулся с перерыва\n", formatTime(r.clock.now), m.title())
+		r.emit(m.event(evBreakEnded))
+		r.setPresent(m, true, "cover")
+	}
+	return true
+}
+
+// dutyDeadline — момент, когда свободного сотрудника надо оторвать от
+// ожидания работы: перерыв или конец смены; нулевое время — ждать сколько угодно
+func (r *Restaurant) dutyDeadline(m *StaffMember) time.Time {
+	var deadline time.Time
+	if m.BreakAfter > 0 && !m.breakTaken && !r.interrupted {
+		deadline = m.Start.Add(m.BreakAfter)
+	}
+	if r.leavesBeforeClose(m) && (deadline.IsZero() || m.End.Before(deadline)) {
+		deadline = m.End
+	}
+	return deadline
+}
+
+// Состояния сотрудника для веб-панели, когда он не занят делом
+const (
+	offDuty = "не на смене"
+	onBreak = "перерыв"
+)
@@ -0,0 +1,833 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// === Сценарии ===
+// Сценарий описывает смену целиком и хранится в JSON, чтобы прогоны шли
+// без диалога и лежали в git рядом с кодом:
+//
+//	go run . run -scenario scenarios/weekday.json -seed 7
+//
+// Флаги командной строки переопределяют поля файла.
+
+type Scenario struct {
+	Chefs    int          `json:"chefs"`
+	Waiters  int          `json:"waiters"`
+	Tables   int          `json:"tables"`
+	Menu     string       `json:"menu,omitempty"` // файл меню JSON; пусто — стандартное меню
+	Open     string       `json:"open"`           // время открытия, "11:00"
+	Duration string       `json:"duration"`       // длина дня, "11h"
+	Arrivals ArrivalModel `json:"arrivals"`
+	Roster   []ShiftSpec  `json:"roster,omitempty"` // если задан, chefs и waiters считаются по нему
+	Patience string       `json:"patience"`         // распределение и среднее в минутах, "exp:40"
+	Policy   string       `json:"policy"`
+	Billing  BillingSpec  `json:"billing"`
+
+	Assignment string `json:"assignment"`           // закрепление столов: pool, sections, round_robin, least_loaded
+	MaxTables  int    `json:"max_tables,omitempty"` // столов на официанта одновременно; 0 — без ограничения
+
+	Courses CourseSpec  `json:"courses"`
+	Offsite OffsiteSpec `json:"offsite"`
+	Booking BookingSpec `json:"reservations"`
+	Seed    int64       `json:"seed,omitempty"` // 0 — случайный
+
+	Incidents []IncidentSpec `json:"incidents,omitempty"`
+
+	Ingredients   []Ingredient `json:"ingredients,omitempty"` // остатки на открытие; пусто — без учёта склада
+	ReorderChance float64      `json:"reorder_chance"`        // доля гостей, готовых заказать другое блюдо
+}
+
+// CourseSpec — доли гостей, берущих закуску и десерт, и сколько едят каждый
+// курс: распределение и среднее в минутах, как у терпения
+type CourseSpec struct {
+	StarterChance float64           `json:"starter_chance"`
+	DessertChance float64           `json:"dessert_chance"`
+	Eat           map[string]string `json:"eat"`
+}
+
+// BookingSpec — книга брони; per_hour — броней на каждый час окна from–to,
+// нулевая частота — только гости с улицы. Пустое окно — от открытия до
+// последних посадок
+type BookingSpec struct {
+	PerHour    float64 `json:"per_hour"`
+	From       string  `json:"from,omitempty"` // "18:00"
+	To         string  `json:"to,omitempty"`   // "21:00"
+	Slot       string  `json:"slot"`           // "30m" — шаг слотов
+	TableTime  string  `json:"table_time"`     // "90m" — на сколько бронь занимает стол
+	HoldBefore string  `json:"hold_before"`    // "30m" — за сколько до слота придерживать стол
+	Grace      string  `json:"grace"`          // "15m" — сколько ждать опоздавших
+	NoShow     float64 `json:"no_show"`
+	LateChance float64 `json:"late_chance"`
+	Late       string  `json:"late"` // опоздание: распределение и среднее в минутах, как у терпения
+}
+
+// IncidentSpec — происшествие по ходу смены: kind staff_out, staff_in,
+// station_down, rush или menu_off; время суток — как у графика смен
+type IncidentSpec struct {
+	At       string   `json:"at"`              // "18:30"
+	Until    string   `json:"until,omitempty"` // пусто — до конца дня
+	Kind     string   `json:"kind"`
+	Title    string   `json:"title,omitempty"`
+	Role     string   `json:"role,omitempty"`     // staff_out, staff_in: chef или waiter
+	Count    int      `json:"count,omitempty"`    // сотрудников или компаний наплыва
+	Station  string   `json:"station,omitempty"`  // station_down
+	Capacity int      `json:"capacity,omitempty"` // station_down: сколько мест остаётся
+	Size     int      `json:"size,omitempty"`     // rush: гостей в компании; 0 — как обычно
+	Dishes   []string `json:"dishes,omitempty"`   // menu_off
+}
+
+// OffsiteSpec — заказы навынос и с доставкой; частоты в заказах в час,
+// нулевая частота выключает канал
+type OffsiteSpec struct {
+	TakeawayPerHour float64    `json:"takeaway_per_hour"`
+	DeliveryPerHour float64    `json:"delivery_per_hour"`
+	TakeawayQuote   string     `json:"takeaway_quote"` // "40m" — через сколько обещают выдать
+	DeliveryQuote   string     `json:"delivery_quote"` // "60m" — через сколько обещают привезти
+	MaxDishes       int        `json:"max_dishes"`
+	Couriers        int        `json:"couriers"`
+	Zones           []ZoneSpec `json:"zones,omitempty"`
+}
+
+// ZoneSpec — район доставки: доля заказов и дорога в одну сторону,
+// распределение и среднее в минутах, как у терпения
+type ZoneSpec struct {
+	Name   string  `json:"name"`
+	Share  float64 `json:"share"`
+	Travel string  `json:"travel"`
+}
+
+// BillingSpec — правила кассы в сценарии; доли задаются числами от 0 до 1
+type BillingSpec struct {
+	VAT             float64        `json:"vat"`
+	ServiceCharge   float64        `json:"service_charge"`
+	ServiceMinParty int            `json:"service_min_party,omitempty"`
+	HappyHour       *HappyHourSpec `json:"happy_hour,omitempty"`
+	Combos          []Combo        `json:"combos,omitempty"`
+	Tips            TipSpec        `json:"tips"`
+	SplitChance     float64        `json:"split_chance"`
+	CardShare       float64        `json:"card_share"`
+}
+
+type HappyHourSpec struct {
+	From     string   `json:"from"` // "15:00"
+	To       string   `json:"to"`   // "17:00"
+	Discount float64  `json:"discount"`
+	Stations []string `json:"stations,omitempty"`
+}
+
+type TipSpec struct {
+	Rate   float64 `json:"rate"`
+	Chance float64 `json:"chance"`
+	Target string  `json:"target"` // "30m" от посадки до подачи — полные чаевые
+	Zero   string  `json:"zero"`   // "60m" и дольше — без чаевых
+}
+
+// ArrivalModel задаёт, сколько гостей приходит за час
+type ArrivalModel struct {
+	Kind           string  `json:"kind"`             // uniform или poisson
+	GuestsPerTable float64 `json:"guests_per_table"` // в среднем гостей в час на стол
+}
+
+// ShiftSpec — строка графика в сценарии
+type ShiftSpec struct {
+	Name        string `json:"name"`
+	Role        string `json:"role"` // chef или waiter
+	Count       int    `json:"count"`
+	Start       string `json:"start"`                  // "10:00"
+	End         string `json:"end"`                    // "16:00"; раньше начала — смена через полночь
+	BreakAfter  string `json:"break_after,omitempty"`  // "3h" от начала смены
+	BreakLength string `json:"break_length,omitempty"` // "30m"
+}
+
+func defaultScenario() Scenario {
+	return Scenario{
+		Chefs:    3,
+		Waiters:  5,
+		Tables:   10,
+		Open:     "11:00",
+		Duration: "11h",
+		Arrivals: ArrivalModel{Kind: "uniform", GuestsPerTable: 2.5},
+		Patience: "exp:40",
+		Policy:   "fifo",
+
+		Assignment:    "pool",
+		ReorderChance: 0.6,
+		Courses: CourseSpec{
+			StarterChance: 0.5,
+			DessertChance: 0.4,
+			Eat:           map[string]string{courseStarter: "uniform:12", courseMain: "uniform:30", courseDessert: "uniform:15"},
+		},
+		Billing: BillingSpec{
+			VAT:         0.2,
+			Tips:        TipSpec{Rate: 0.1, Chance: 0.7, Target: "30m", Zero: "60m"},
+			SplitChance: 0.3,
+			CardShare:   0.8,
+		},
+		Offsite: OffsiteSpec{TakeawayQuote: "40m", DeliveryQuote: "60m", MaxDishes: 3},
+		Booking: BookingSpec{
+			Slot:       "30m",
+			TableTime:  "90m",
+			HoldBefore: "30m",
+			Grace:      "15m",
+			NoShow:     0.1,
+			LateChance: 0.3,
+			Late:       "exp:10",
+		},
+	}
+}
+
+// guests возвращает число гостей за ближайший час. Равномерная модель даёт
+// от 1 до 2·guests_per_table гостей на стол, пуассоновская — поток с тем же
+// средним, в котором бывают и пустые часы.
+func (m ArrivalModel) guests(rng *rand.Rand, tables int) int {
+	mean := m.GuestsPerTable * float64(tables)
+	if m.Kind == "poisson" {
+		return poisson(rng, mean)
+	}
+	return rng.Intn(int(math.Round(2*mean))+1) + 1
+}
+
+func loadScenario(path string) (Scenario, error) {
+	sc := defaultScenario()
+	f, err := os.Open(path)
+	if err != nil {
+		return sc, fmt.Errorf("ошибка при открытии сценария: %v", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return sc, fmt.Errorf("ошибка в сценарии %s: %v", path, err)
+	}
+	// путь к меню считается от каталога сценария
+	if sc.Menu != "" && !filepath.IsAbs(sc.Menu) {
+		sc.Menu = filepath.Join(filepath.Dir(path), sc.Menu)
+	}
+	return sc, nil
+}
+
+func loadMenu(path string) ([]Dish, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("menu: %v", err)
+	}
+	defer f.Close()
+
+	var menu []Dish
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&menu); err != nil {
+		return nil, fmt.Errorf("menu: ошибка в %s: %v", path, err)
+	}
+	if len(menu) == 0 {
+		return nil, fmt.Errorf("menu: меню %s пустое", path)
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for i, d := range menu {
+		switch {
+		case d.Name == "":
+			errs = append(errs, fmt.Errorf("menu[%d]: не указано название", i))
+		case seen[d.Name]:
+			errs = append(errs, fmt.Errorf("menu[%d]: блюдо %q встречается дважды", i, d.Name))
+		}
+		seen[d.Name] = true
+		if d.BasePrice <= 0 {
+			errs = append(errs, fmt.Errorf("menu[%d]: цена должна быть больше нуля", i))
+		}
+		if d.MinCookTime <= 0 || d.MaxCookTime < d.MinCookTime {
+			errs = append(errs, fmt.Errorf("menu[%d]: время готовки должно быть 0 < min_cook_min <= max_cook_min", i))
+		}
+		if !knownStation(d.Station) {
+			errs = append(errs, fmt.Errorf("menu[%d]: неизвестная станция %q", i, d.Station))
+		}
+		if _, ok := courseTitles[d.course()]; !ok {
+			errs = append(errs, fmt.Errorf("menu[%d]: курс должен быть starter, main или dessert, получено %q", i, d.Course))
+		}
+		for _, name := range recipeIngredients(d) {
+			if d.Recipe[name] <= 0 {
+				errs = append(errs, fmt.Errorf("menu[%d]: количество продукта %q в рецепте должно быть больше нуля", i, name))
+			}
+		}
+	}
+	return menu, errors.Join(errs...)
+}
+
+func knownStation(name string) bool {
+	for _, st := range kitchenStations {
+		if st.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// config проверяет сценарий целиком и возвращает все найденные ошибки разом
+func (sc Scenario) config() (SimConfig, error) {
+	cfg := SimConfig{
+		Chefs:    sc.Chefs,
+		Waiters:  sc.Waiters,
+		Tables:   sc.Tables,
+		Menu:     dishes,
+		Arrivals: sc.Arrivals,
+		Seed:     sc.Seed,
+	}
+	var errs []error
+	if sc.Chefs < 1 && len(sc.Roster) == 0 {
+		errs = append(errs, fmt.Errorf("chefs: нужен хотя бы один повар"))
+	}
+	if sc.Waiters < 1 && len(sc.Roster) == 0 {
+		errs = append(errs, fmt.Errorf("waiters: нужен хотя бы один официант"))
+	}
+	if sc.Tables < 1 {
+		errs = append(errs, fmt.Errorf("tables: нужен хотя бы один стол"))
+	}
+	if sc.Menu != "" {
+		menu, err := loadMenu(sc.Menu)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		cfg.Menu = menu
+	}
+	if openAt, err := parseClock(sc.Open); err != nil {
+		errs = append(errs, fmt.Errorf("open: %v", err))
+	} else {
+		cfg.OpenAt = openAt
+	}
+	if d, err := time.ParseDuration(sc.Duration); err != nil || d <= lastOrdersBeforeClose || d > 24*time.Hour {
+		errs = append(errs, fmt.Errorf("duration: длина дня должна быть больше %v и не больше 24h, получено %q",
+			lastOrdersBeforeClose, sc.Duration))
+	} else {
+		cfg.Duration = d
+	}
+	if len(sc.Roster) > 0 {
+		roster, rosterErrs := parseRoster(sc.Roster, cfg.OpenAt, cfg.OpenAt+cfg.Duration)
+		errs = append(errs, rosterErrs...)
+		cfg.Roster = roster
+		cfg.Chefs, cfg.Waiters = 0, 0
+		for _, plan := range roster {
+			if plan.Role == "chef" {
+				cfg.Chefs += plan.Count
+			} else {
+				cfg.Waiters += plan.Count
+			}
+		}
+	}
+	if sc.Arrivals.Kind != "uniform" && sc.Arrivals.Kind != "poisson" {
+		errs = append(errs, fmt.Errorf("arrivals.kind: ожидается uniform или poisson, получено %q", sc.Arrivals.Kind))
+	}
+	if sc.Arrivals.GuestsPerTable <= 0 {
+		errs = append(errs, fmt.Errorf("arrivals.guests_per_table: должно быть больше нуля"))
+	}
+	if patience, err := parseDistributionSpec(sc.Patience); err != nil {
+		errs = append(errs, fmt.Errorf("patience: %v", err))
+	} else {
+		cfg.Patience = patience
+	}
+	if policy, err := findPolicy(sc.Policy); err != nil {
+		errs = append(errs, fmt.Errorf("policy: %v", err))
+	} else {
+		cfg.Policy = policy
+	}
+	if assignment, err := findAssignment(sc.Assignment); err != nil {
+		errs = append(errs, fmt.Errorf("assignment: %v", err))
+	} else {
+		cfg.Assignment = assignment
+	}
+	if sc.MaxTables < 0 {
+		errs = append(errs, fmt.Errorf("max_tables: не может быть отрицательным, получено %d", sc.MaxTables))
+	}
+	cfg.MaxTables = sc.MaxTables
+	billing, billingErrs := parseBilling(sc.Billing, cfg.Menu)
+	errs = append(errs, billingErrs...)
+	cfg.Billing = billing
+	model, courseErrs := parseCourses(sc.Courses, cfg.Menu)
+	errs = append(errs, courseErrs...)
+	cfg.Courses = model
+	offsite, offsiteErrs := parseOffsite(sc.Offsite)
+	errs = append(errs, offsiteErrs...)
+	cfg.Offsite = offsite
+	booking, bookingErrs := parseBooking(sc.Booking, cfg.OpenAt, cfg.OpenAt+cfg.Duration-lastOrdersBeforeClose)
+	errs = append(errs, bookingErrs...)
+	cfg.Reservations = booking
+	incidents, incidentErrs := parseIncidents(sc.Incidents, cfg.Menu, cfg.OpenAt, cfg.OpenAt+cfg.Duration-lastOrdersBeforeClose)
+	errs = append(errs, incidentErrs...)
+	cfg.Incidents = incidents
+	errs = append(errs, checkIngredients(sc.Ingredients, cfg.Menu)...)
+	cfg.Ingredients = sc.Ingredients
+	if sc.ReorderChance < 0 || sc.ReorderChance > 1 {
+		errs = append(errs, fmt.Errorf("reorder_chance: ожидается доля от 0 до 1, получено %v", sc.ReorderChance))
+	}
+	cfg.ReorderChance = sc.ReorderChance
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// parseRoster проверяет график: время смен, перерывы и то, что к закрытию
+// остаётся хотя бы один повар и один официант
+func parseRoster(specs []ShiftSpec, openAt, closeAt time.Duration) ([]ShiftPlan, []error) {
+	var roster []ShiftPlan
+	var errs []error
+	coversClose := map[string]bool{}
+	for i, spec := range specs {
+		plan := ShiftPlan{Name: spec.Name, Role: spec.Role, Count: spec.Count}
+		if plan.Name == "" {
+			plan.Name = fmt.Sprintf("смена %d", i+1)
+		}
+		if spec.Role != "chef" && spec.Role != "waiter" {
+			errs = append(errs, fmt.Errorf("roster[%d].role: ожидается chef или waiter, получено %q", i, spec.Role))
+		}
+		if spec.Count < 1 {
+			errs = append(errs, fmt.Errorf("roster[%d].count: нужен хотя бы один сотрудник", i))
+		}
+		start, err1 := parseClock(spec.Start)
+		if err1 != nil {
+			errs = append(errs, fmt.Errorf("roster[%d].start: %v", i, err1))
+		}
+		end, err2 := parseClock(spec.End)
+		if err2 != nil {
+			errs = append(errs, fmt.Errorf("roster[%d].end: %v", i, err2))
+		}
+		if err1 == nil && err2 == nil {
+			if end <= start {
+				end += 24 * time.Hour
+			}
+			if start < openAt && end <= openAt {
+				start += 24 * time.Hour
+				end += 24 * time.Hour
+			}
+			if start >= closeAt {
+				errs = append(errs, fmt.Errorf("roster[%d]: смена начинается после закрытия", i))
+			}
+			plan.Start, plan.End = start, end
+			if end >= closeAt {
+				coversClose[spec.Role] = true
+			}
+		}
+		if spec.BreakAfter != "" || spec.BreakLength != "" {
+			after, err1 := time.ParseDuration(spec.BreakAfter)
+			length, err2 := time.ParseDuration(spec.BreakLength)
+			switch {
+			case err1 != nil || err2 != nil || after <= 0 || length <= 0:
+				errs = append(errs, fmt.Errorf("roster[%d]: перерыв задаётся парой break_after и break_length, например 3h и 30m", i))
+			case plan.End > plan.Start && after+length > plan.End-plan.Start:
+				errs = append(errs, fmt.Errorf("roster[%d]: перерыв не помещается в смену", i))
+			default:
+				plan.BreakAfter, plan.BreakLength = after, length
+			}
+		}
+		roster = append(roster, plan)
+	}
+	if !coversClose["chef"] {
+		errs = append(errs, fmt.Errorf("roster: к закрытию не остаётся ни одного повара"))
+	}
+	if !coversClose["waiter"] {
+		errs = append(errs, fmt.Errorf("roster: к закрытию не остаётся ни одного официанта"))
+	}
+	return roster, errs
+}
+
+// parseCourses проверяет доли закусок и десертов и время, за которое едят
+// каждый курс; в меню должно быть хотя бы одно основное блюдо
+func parseCourses(spec CourseSpec, menu []Dish) (CourseModel, []error) {
+	model := CourseModel{
+		StarterChance: spec.StarterChance,
+		DessertChance: spec.DessertChance,
+		Eat:           make(map[string]distribution),
+	}
+	var errs []error
+	if spec.StarterChance < 0 || spec.StarterChance > 1 {
+		errs = append(errs, fmt.Errorf("courses.starter_chance: ожидается доля от 0 до 1, получено %v", spec.StarterChance))
+	}
+	if spec.DessertChance < 0 || spec.DessertChance > 1 {
+		errs = append(errs, fmt.Errorf("courses.dessert_chance: ожидается доля от 0 до 1, получено %v", spec.DessertChance))
+	}
+	for _, course := range courses {
+		d, err := parseDistributionSpec(spec.Eat[course])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("courses.eat.%s: %v", course, err))
+		}
+		model.Eat[course] = d
+	}
+	names := make([]string, 0, len(spec.Eat))
+	for course := range spec.Eat {
+		names = append(names, course)
+	}
+	sort.Strings(names)
+	for _, course := range names {
+		if _, ok := courseTitles[course]; !ok {
+			errs = append(errs, fmt.Errorf("courses.eat: неизвестный курс %q", course))
+		}
+	}
+	hasMain := false
+	for _, d := range menu {
+		hasMain = hasMain || d.course() == courseMain
+	}
+	if len(menu) > 0 && !hasMain {
+		errs = append(errs, fmt.Errorf("menu: нужно хотя бы одно основное блюдо (course: main)"))
+	}
+	return model, errs
+}
+
+// parseBooking проверяет правила брони; слоты должны укладываться между
+// открытием и последними посадками lastSeating
+func parseBooking(spec BookingSpec, openAt, lastSeating time.Duration) (ReservationModel, []error) {
+	m := ReservationModel{
+		PerHour:    spec.PerHour,
+		From:       openAt,
+		To:         lastSeating,
+		NoShow:     spec.NoShow,
+		LateChance: spec.LateChance,
+	}
+	var errs []error
+	if spec.PerHour < 0 {
+		errs = append(errs, fmt.Errorf("reservations.per_hour: не может быть отрицательным"))
+	}
+	if spec.PerHour <= 0 {
+		return m, errs
+	}
+	duration := func(field, s string) time.Duration {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("reservations.%s: ожидается длительность вида 30m, получено %q", field, s))
+		}
+		return d
+	}
+	m.Slot = duration("slot", spec.Slot)
+	m.TableTime = duration("table_time", spec.TableTime)
+	m.HoldBefore = duration("hold_before", spec.HoldBefore)
+	m.Grace = duration("grace", spec.Grace)
+	window := func(field, s string, def time.Duration) time.Duration {
+		if s == "" {
+			return def
+		}
+		at, err := parseClock(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reservations.%s: %v", field, err))
+			return def
+		}
+		if at < openAt {
+			at += 24 * time.Hour // окно после полуночи
+		}
+		if at > lastSeating {
+			errs = append(errs, fmt.Errorf("reservations.%s: %s позже последних посадок", field, s))
+		}
+		return at
+	}
+	m.From = window("from", spec.From, openAt)
+	m.To = window("to", spec.To, lastSeating)
+	if m.To <= m.From {
+		errs = append(errs, fmt.Errorf("reservations: окно брони пустое, from должно быть раньше to"))
+	}
+	if spec.NoShow < 0 || spec.NoShow > 1 {
+		errs = append(errs, fmt.Errorf("reservations.no_show: ожидается доля от 0 до 1, получено %v", spec.NoShow))
+	}
+	if spec.LateChance < 0 || spec.LateChance > 1 {
+		errs = append(errs, fmt.Errorf("reservations.late_chance: ожидается доля от 0 до 1, получено %v", spec.LateChance))
+	}
+	late, err := parseDistributionSpec(spec.Late)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reservations.late: %v", err))
+	}
+	m.Late = late
+	return m, errs
+}
+
+// parseIncidents проверяет происшествия: каждое начинается и заканчивается
+// между открытием и последними заказами lastOrders
+func parseIncidents(specs []IncidentSpec, menu []Dish, openAt, lastOrders time.Duration) ([]Incident, []error) {
+	var incidents []Incident
+	var errs []error
+	for i, spec := range specs {
+		inc := Incident{ID: i + 1, Kind: spec.Kind, Title: spec.Title, Role: spec.Role, Count: spec.Count,
+			Station: spec.Station, Capacity: spec.Capacity, Size: spec.Size, Dishes: spec.Dishes}
+		clock := func(field, s string) time.Duration {
+			at, err := parseClock(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("incidents[%d].%s: %v", i, field, err))
+				return 0
+			}
+			if at < openAt {
+				at += 24 * time.Hour // после полуночи
+			}
+			if at >= lastOrders {
+				errs = append(errs, fmt.Errorf("incidents[%d].%s: %s не раньше последних заказов", i, field, s))
+			}
+			return at
+		}
+		inc.At = clock("at", spec.At)
+		if spec.Until != "" {
+			inc.Until = clock("until", spec.Until)
+			if inc.At > 0 && inc.Until > 0 && inc.Until <= inc.At {
+				errs = append(errs, fmt.Errorf("incidents[%d]: until должно быть позже at", i))
+			}
+		}
+		role := func() {
+			if spec.Role != "chef" && spec.Role != "waiter" {
+				errs = append(errs, fmt.Errorf("incidents[%d].role: ожидается chef или waiter, получено %q", i, spec.Role))
+			}
+		}
+		switch spec.Kind {
+		case incidentStaffOut:
+			role()
+			if inc.Count == 0 {
+				inc.Count = 1
+			}
+			if inc.Count < 0 {
+				errs = append(errs, fmt.Errorf("incidents[%d].count: не может быть отрицательным", i))
+			}
+			if spec.Until != "" {
+				errs = append(errs, fmt.Errorf("incidents[%d].until: ушедший домой не возвращается, для возврата добавьте staff_in", i))
+			}
+		case incidentStaffIn:
+			role()
+			if inc.Count < 1 {
+				errs = append(errs, fmt.Errorf("incidents[%d].count: нужен хотя бы один сотрудник", i))
+			}
+		case incidentStationDown:
+			capacity := -1
+			for _, st := range kitchenStations {
+				if st.Name == spec.Station {
+					capacity = st.Capacity
+				}
+			}
+			switch {
+			case capacity < 0:
+				errs = append(errs, fmt.Errorf("incidents[%d].station: неизвестная станция %q", i, spec.Station))
+			case spec.Capacity < 0 || spec.Capacity >= capacity:
+				errs = append(errs, fmt.Errorf("incidents[%d].capacity: ожидается от 0 до %d, получено %d", i, capacity-1, spec.Capacity))
+			}
+			if spec.Until == "" {
+				errs = append(errs, fmt.Errorf("incidents[%d].until: нужно время починки станции", i))
+			}
+		case incidentRush:
+			if inc.Count < 1 {
+				errs = append(errs, fmt.Errorf("incidents[%d].count: нужна хотя бы одна компания", i))
+			}
+			largest := 0
+			for _, c := range tableCapacities {
+				largest = max(largest, c)
+			}
+			if spec.Size < 0 || spec.Size > largest {
+				errs = append(errs, fmt.Errorf("incidents[%d].size: ожидается от 1 до %d гостей, получено %d", i, largest, spec.Size))
+			}
+			if spec.Until == "" {
+				inc.Until = min(inc.At+30*time.Minute, lastOrders)
+			}
+		case incidentMenuOff:
+			if len(spec.Dishes) == 0 {
+				errs = append(errs, fmt.Errorf("incidents[%d].dishes: не указаны блюда", i))
+			}
+			for _, name := range spec.Dishes {
+				found := false
+				for _, d := range menu {
+					found = found || d.Name == name
+				}
+				if !found {
+					errs = append(errs, fmt.Errorf("incidents[%d].dishes: блюда %q нет в меню", i, name))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("incidents[%d].kind: ожидается staff_out, staff_in, station_down, rush или menu_off, получено %q", i, spec.Kind))
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, errs
+}
+
+func parseOffsite(spec OffsiteSpec) (OffsiteModel, []error) {
+	m := OffsiteModel{
+		TakeawayRate: spec.TakeawayPerHour,
+		DeliveryRate: spec.DeliveryPerHour,
+		MaxDishes:    spec.MaxDishes,
+		Couriers:     spec.Couriers,
+	}
+	var errs []error
+	if spec.TakeawayPerHour < 0 || spec.DeliveryPerHour < 0 {
+		errs = append(errs, fmt.Errorf("offsite: число заказов в час не может быть отрицательным"))
+	}
+	if spec.TakeawayPerHour == 0 && spec.DeliveryPerHour == 0 {
+		return m, errs
+	}
+	if spec.MaxDishes < 1 {
+		errs = append(errs, fmt.Errorf("offsite.max_dishes: нужно хотя бы одно блюдо"))
+	}
+	quote := func(field, s string) time.Duration {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("offsite.%s: ожидается длительность вида 30m, получено %q", field, s))
+		}
+		return d
+	}
+	m.TakeawayQuote = quote("takeaway_quote", spec.TakeawayQuote)
+	m.DeliveryQuote = quote("delivery_quote", spec.DeliveryQuote)
+	if spec.DeliveryPerHour == 0 {
+		return m, errs
+	}
+	if spec.Couriers < 1 {
+		errs = append(errs, fmt.Errorf("offsite.couriers: для доставки нужен хотя бы один курьер"))
+	}
+	if len(spec.Zones) == 0 {
+		errs = append(errs, fmt.Errorf("offsite.zones: для доставки нужен хотя бы один район"))
+	}
+	seen := make(map[string]bool)
+	for i, z := range spec.Zones {
+		switch {
+		case z.Name == "":
+			errs = append(errs, fmt.Errorf("offsite.zones[%d]: не указано название", i))
+		case seen[z.Name]:
+			errs = append(errs, fmt.Errorf("offsite.zones[%d]: район %q встречается дважды", i, z.Name))
+		}
+		seen[z.Name] = true
+		if z.Share <= 0 {
+			errs = append(errs, fmt.Errorf("offsite.zones[%d].share: должна быть больше нуля", i))
+		}
+		travel, err := parseDistributionSpec(z.Travel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("offsite.zones[%d].travel: %v", i, err))
+		}
+		m.Zones = append(m.Zones, Zone{Name: z.Name, Share: z.Share, Travel: travel})
+	}
+	return m, errs
+}
+
+// checkIngredients проверяет остатки склада и то, что все продукты из рецептов
+// меню в них перечислены
+func checkIngredients(ingredients []Ingredient, menu []Dish) []error {
+	if len(ingredients) == 0 {
+		return nil
+	}
+	var errs []error
+	known := make(map[string]bool)
+	for i, ing := range ingredients {
+		switch {
+		case ing.Name == "":
+			errs = append(errs, fmt.Errorf("ingredients[%d]: не указано название", i))
+		case known[ing.Name]:
+			errs = append(errs, fmt.Errorf("ingredients[%d]: продукт %q встречается дважды", i, ing.Name))
+		}
+		known[ing.Name] = true
+		if ing.Stock < 0 || ing.Cost < 0 {
+			errs = append(errs, fmt.Errorf("ingredients[%d]: остаток и цена не могут быть отрицательными", i))
+		}
+	}
+	for _, d := range menu {
+		for _, name := range recipeIngredients(d) {
+			if !known[name] {
+				errs = append(errs, fmt.Errorf("ingredients: нет продукта %q из рецепта блюда %q", name, d.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// parseBilling проверяет правила кассы: доли, скидки акций и блюда комбо
+func parseBilling(spec BillingSpec, menu []Dish) (Billing, []error) {
+	b := Billing{
+		VAT:             spec.VAT,
+		ServiceCharge:   spec.ServiceCharge,
+		ServiceMinParty: spec.ServiceMinParty,
+		Combos:          spec.Combos,
+		SplitChance:     spec.SplitChance,
+		CardShare:       spec.CardShare,
+		Tips:            TipModel{Rate: spec.Tips.Rate, Chance: spec.Tips.Chance},
+	}
+	var errs []error
+	share := func(field string, v float64) {
+		if v < 0 || v > 1 {
+			errs = append(errs, fmt.Errorf("billing.%s: ожидается доля от 0 до 1, получено %v", field, v))
+		}
+	}
+	share("vat", spec.VAT)
+	share("service_charge", spec.ServiceCharge)
+	share("split_chance", spec.SplitChance)
+	share("card_share", spec.CardShare)
+	share("tips.rate", spec.Tips.Rate)
+	share("tips.chance", spec.Tips.Chance)
+	if spec.ServiceMinParty < 0 {
+		errs = append(errs, fmt.Errorf("billing.service_min_party: не может быть отрицательным"))
+	}
+
+	target, err1 := time.ParseDuration(spec.Tips.Target)
+	zero, err2 := time.ParseDuration(spec.Tips.Zero)
+	if err1 != nil || err2 != nil || target <= 0 || zero <= target {
+		errs = append(errs, fmt.Errorf("billing.tips: нужны длительности 0 < target < zero, например 30m и 60m"))
+	}
+	b.Tips.Target, b.Tips.Zero = target, zero
+
+	if h := spec.HappyHour; h != nil {
+		b.HappyHour = &HappyHour{Discount: h.Discount, Stations: h.Stations}
+		var err error
+		if b.HappyHour.From, err = parseClock(h.From); err != nil {
+			errs = append(errs, fmt.Errorf("billing.happy_hour.from: %v", err))
+		}
+		if b.HappyHour.To, err = parseClock(h.To); err != nil {
+			errs = append(errs, fmt.Errorf("billing.happy_hour.to: %v", err))
+		}
+		if h.Discount <= 0 || h.Discount >= 1 {
+			errs = append(errs, fmt.Errorf("billing.happy_hour.discount: ожидается доля больше 0 и меньше 1"))
+		}
+		for _, st := range h.Stations {
+			if !knownStation(st) {
+				errs = append(errs, fmt.Errorf("billing.happy_hour.stations: неизвестная станция %q", st))
+			}
+		}
+	}
+
+	onMenu := make(map[string]bool)
+	for _, d := range menu {
+		onMenu[d.Name] = true
+	}
+	for i, c := range spec.Combos {
+		if c.Name == "" {
+			errs = append(errs, fmt.Errorf("billing.combos[%d]: не указано название", i))
+		}
+		if len(c.Dishes) < 2 {
+			errs = append(errs, fmt.Errorf("billing.combos[%d]: в комбо нужно хотя бы два блюда", i))
+		}
+		for _, name := range c.Dishes {
+			if !onMenu[name] {
+				errs = append(errs, fmt.Errorf("billing.combos[%d]: блюда %q нет в меню", i, name))
+			}
+		}
+		if c.Discount <= 0 || c.Discount >= 1 {
+			errs = append(errs, fmt.Errorf("billing.combos[%d].discount: ожидается доля больше 0 и меньше 1", i))
+		}
+	}
+	return b, errs
+}
+
+// parseClock разбирает время суток "16:00" в смещение от полуночи
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("ожидается время вида 11:00, получено %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseDistributionSpec разбирает "exp:40" — распределение и среднее в минутах
+func parseDistributionSpec(s string) (distribution, error) {
+	kind, mean, _ := strings.Cut(s, ":")
+	m, err := strconv.Atoi(mean)
+	if err != nil || m <= 0 {
+		return distribution{}, fmt.Errorf("некорректное распределение %q, например exp:40", s)
+	}
+	return parseDistribution(kind, time.Duration(m)*time.Minute)
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// === Рассадка гостей ===
+
+type partyState int
+
+const (
+	partyWaiting partyState = iota
+	partySeated
+	partyOrdered
+	partyServed
+	partyPaid
+	partyLeft
+)
+
+func (s partyState) String() string {
+	switch s {
+	case partyWaiting:
+		return "waiting"
+	case partySeated:
+		return "seated"
+	case partyOrdered:
+		return "ordered"
+	case partyServed:
+		return "eating"
+	case partyPaid:
+		return "paid"
+	default:
+		return "left"
+	}
+}
+
+type Party struct {
+	ID       int
+	Size     int
+	Patience time.Duration
+	Arrived  time.Time
+	SeatedAt time.Time
+	Table    *Table
+	Order    *Order
+	Booking  *Booking     // nil — пришли без брони
+	Waiter   *StaffMember // официант, за которым закреплён стол; nil — общий зал
+	state    partyState
+	cond     simCond
+}
+
+type Table struct {
+	ID       int
+	Capacity int
+	VIP      bool
+	Party    *Party
+	held     *Booking // стол придержан под бронь, других гостей за него не сажают
+}
+
+type taskKind int
+
+const (
+	taskTakeOrder taskKind = iota
+	taskDeliver
+	taskBill
+	taskClear
+)
+
+type floorTask struct {
+	kind  taskKind
+	party *Party
+	table *Table
+}
+
+func (t floorTask) String() string {
+	switch t.kind {
+	case taskTakeOrder:
+		return fmt.Sprintf("принимает заказ стола %d", t.table.ID)
+	case taskDeliver:
+		return fmt.Sprintf("несёт заказ на стол %d", t.table.ID)
+	case taskBill:
+		return fmt.Sprintf("рассчитывает стол %d", t.table.ID)
+	default:
+		return fmt.Sprintf("убирает стол %d", t.table.ID)
+	}
+}
+
+type kitchenTicket struct {
+	id     int
+	order  *Order
+	dish   Dish
+	queued time.Time
+}
+
+type Floor struct {
+	tables []*Table
+	queue  []*Party // очередь у хоста
+	tasks  *simQueue[floorTask]
+	closed bool
+	empty  simCond
+}
+
+func newFloor(c *simClock, numTables int) *Floor {
+	f := &Floor{
+		tasks: newSimQueue[floorTask](c),
+		empty: simCond{clock: c},
+	}
+	for i := 1; i <= numTables; i++ {
+		f.tables = append(f.tables, &Table{
+			ID:       i,
+			Capacity: tableCapacities[(i-1)%len(tableCapacities)],
+			VIP:      i%vipEvery == 0,
+		})
+	}
+	return f
+}
+
+func (f *Floor) maxCapacity() int {
+	maxCap := 0
+	for _, t := range f.tables {
+		if t.Capacity > maxCap {
+			maxCap = t.Capacity
+		}
+	}
+	return maxCap
+}
+
+// freeTable подбирает компании стол: по брони — придержанный для неё,
+// иначе самый маленький свободный и не придержанный стол, за который она
+// поместится. Годятся только столы, для которых servable находит официанта.
+func (f *Floor) freeTable(p *Party, servable func(*Table) bool) *Table {
+	if b := p.Booking; b != nil && b.Table.held == b && b.Table.Party == nil && servable(b.Table) {
+		return b.Table
+	}
+	var best *Table
+	for _, t := range f.tables {
+		if t.Party != nil || t.held != nil || t.Capacity < p.Size || !servable(t) {
+			continue
+		}
+		if best == nil || t.Capacity < best.Capacity {
+			best = t
+		}
+	}
+	return best
+}
+
+func (f *Floor) busy() bool {
+	if len(f.queue) > 0 {
+		return true
+	}
+	for _, t := range f.tables {
+		if t.Party != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// seatWaiting рассаживает ожидающих по порядку очереди; компания, для которой
+// пока нет стола или официанта, не задерживает тех, кто стоит за ней
+func (r *Restaurant) seatWaiting() {
+	f := r.floor
+	var rest []*Party
+	for _, p := range f.queue {
+		t := f.freeTable(p, r.servable)
+		if t == nil {
+			rest = append(rest, p)
+			continue
+		}
+		now := r.clock.now
+		t.Party = p
+		p.Table = t
+		p.SeatedAt = now
+		p.state = partySeated
+		seated := Event{Type: evPartySeated, Party: p.ID, Table: t.ID, Size: p.Size}
+		if w, _ := r.cfg.Assignment.assign(r, t); w != nil {
+			w.parties++
+			r.attach(p, w)
+			r.nextWaiter = w.ID % len(r.waiters)
+			seated.Waiter = w.ID
+		}
+		if b := p.Booking; b != nil {
+			// стол по брони больше не держим, даже если гостей посадили за другой
+			if b.Table.held == b {
+				b.Table.held = nil
+			}
+			seated.Booking = b.ID
+		}
+		r.emit(seated)
+		r.logf("[%s] Гости #%d (%d чел.) сели за стол %d (мест: %d), ждали %v\n",
+			formatTime(now), p.ID, p.Size, t.ID, t.Capacity, now.Sub(p.Arrived))
+		p.cond.Signal()
+	}
+	f.queue = rest
+}
+
+func (r *Restaurant) closeDoors() {
+	f := r.floor
+	f.closed = true
+	for _, p := range f.queue {
+		p.state = partyLeft
+		r.emit(Event{Type: evPartyTurnedAway, Party: p.ID, Size: p.Size, Reason: "closing"})
+		p.cond.Signal()
+	}
+	f.queue = nil
+	for _, t := range f.tables {
+		if t.held != nil {
+			r.releaseTable(t.held, "closing")
+		}
+	}
+	f.empty.Broadcast()
+}
+
+func (r *Restaurant) party(p *Party) {
+	f := r.floor
+	r.logf("[%s] Пришли гости #%d (%d чел.)\n", formatTime(p.Arrived), p.ID, p.Size)
+
+	arrived := Event{Type: evPartyArrived, Party: p.ID, Size: p.Size}
+	if p.Booking != nil {
+		arrived.Booking = p.Booking.ID
+	}
+	r.emit(arrived)
+	if f.closed || p.Size > f.maxCapacity() {
+		r.logf("[%s] Гостям #%d не нашлось места\n", formatTime(r.clock.now), p.ID)
+		p.state = partyLeft
+		reason := "no_table"
+		if f.closed {
+			reason = "closing"
+		}
+		r.emit(Event{Type: evPartyTurnedAway, Party: p.ID, Size: p.Size, Reason: reason})
+		return
+	}
+
+	f.enqueue(p)
+	r.emit(Event{Type: evPartyQueued, Party: p.ID, Queue: len(f.queue)})
+	r.seatWaiting()
+	if b := p.Booking; b != nil && p.state == partyWaiting && b.Table.held == b {
+		reason := "table_busy"
+		if b.Table.Party == nil {
+			reason = "no_waiter"
+			r.logf("[%s] Стол %d по брони #%d некому обслужить, гости #%d ждут\n", formatTime(r.clock.now), b.Table.ID, b.ID, p.ID)
+		} else {
+			r.logf("[%s] Стол %d по брони #%d ещё занят, гости #%d ждут\n", formatTime(r.clock.now), b.Table.ID, b.ID, p.ID)
+		}
+		r.emit(Event{Type: evReservationConflict, Booking: b.ID, Party: p.ID, Table: b.Table.ID, Reason: reason})
+	}
+	if !r.waitWhile(p, p.Arrived.Add(p.Patience), partyWaiting) {
+		f.leaveQueue(p)
+		p.state = partyLeft
+		r.emit(Event{Type: evPartyWalkedOut, Party: p.ID, Size: p.Size, Amount: expectedCheck(r.menu, p.Size)})
+		if p.Booking != nil {
+			r.releaseTable(p.Booking, "walked_out")
+		}
+		r.logf("[%s] Гости #%d ушли, не дождавшись стола за %v\n",
+			formatTime(r.clock.now), p.ID, r.clock.now.Sub(p.Arrived))
+		return
+	}
+	if p.Table == nil {
+		r.logf("[%s] Гости #%d ушли, так и не дождавшись стола\n", formatTime(r.clock.now), p.ID)
+		return
+	}
+
+	r.addTask(floorTask{kind: taskTakeOrder, party: p, table: p.Table})
+	if !r.waitWhile(p, p.SeatedAt.Add(p.Patience), partySeated, partyOrdered) {
+		lost := expectedCheck(r.menu, p.Size)
+		orderID := 0
+		if p.Order != nil {
+			p.Order.cancelled = true
+			lost = p.Order.Price
+			orderID = p.Order.OrderID
+		}
+		p.state = partyLeft
+		r.emit(Event{Type: evPartyAbandoned, Party: p.ID, Order: orderID, Table: p.Table.ID, Amount: lost})
+		r.logf("[%s] Гости #%d ушли из-за стола %d, не дождавшись заказа (потеряно %.2f руб.)\n",
+			formatTime(r.clock.now), p.ID, p.Table.ID, lost)
+	}
+
+	if p.state == partyServed {
+		order := p.Order
+		for {
+			r.clock.Sleep(r.cfg.Courses.Eat[order.courseName()].sample(r.rng))
+			r.emit(Event{Type: evCourseEaten, Order: order.OrderID, Table: order.TableID, Course: order.courseName()})
+			order.course++
+			if order.course == len(order.courses) {
+				break
+			}
+			p.state = partyOrdered
+			r.fireCourse(order)
+			for p.state == partyOrdered {
+				p.cond.Wait()
+			}
+		}
+		r.addTask(floorTask{kind: taskBill, party: p, table: p.Table})
+		for p.state == partyServed {
+			p.cond.Wait()
+		}
+	}
+
+	r.logf("[%s] Гости #%d освободили стол %d\n", formatTime(r.clock.now), p.ID, p.Table.ID)
+	r.emit(Event{Type: evPartyLeft, Party: p.ID, Table: p.Table.ID})
+	r.addTask(floorTask{kind: taskClear, party: p, table: p.Table})
+}
+
+// waitWhile ждёт, пока компания находится в одном из состояний states.
+// Возвращает false, если терпение кончилось раньше.
+func (r *Restaurant) waitWhile(p *Party, deadline time.Time, states ...partyState) bool {
+	for {
+		waiting := false
+		for _, st := range states {
+			if p.state == st {
+				waiting = true
+				break
+			}
+		}
+		if !waiting {
+			return true
+		}
+		if !r.clock.now.Before(deadline) {
+			return false
+		}
+		p.cond.WaitTimeout(deadline.Sub(r.clock.now))
+	}
+}
+
+// enqueue ставит компанию в очередь; гости по брони встают перед теми,
+// кто пришёл без неё
+func (f *Floor) enqueue(p *Party) {
+	if p.Booking == nil {
+		f.queue = append(f.queue, p)
+		return
+	}
+	i := 0
+	for i < len(f.queue) && f.queue[i].Booking != nil {
+		i++
+	}
+	f.queue = append(f.queue[:i], append([]*Party{p}, f.queue[i:]...)...)
+}
+
+func (f *Floor) leaveQueue(p *Party) {
+	for i, q := range f.queue {
+		if q == p {
+			f.queue = append(f.queue[:i], f.queue[i+1:]...)
+			break
+		}
+	}
+	if !f.busy() {
+		f.empty.Broadcast()
+	}
+}
+
+// onMenu — есть ли в меню блюда курса
+func (r *Restaurant) onMenu(course string) bool {
+	for _, d := range r.menu {
+		if d.course() == course {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseDish выбирает гостю блюдо курса. Если его нет в наличии, гость
+// берёт другое блюдо того же курса или отказывается от курса (ok == false)
+func (r *Restaurant) chooseDish(course string, p *Party, waiterID int) (Dish, bool) {
+	var options []Dish
+	for _, d := range r.menu {
+		if d.course() == course {
+			options = append(options, d)
+		}
+	}
+	dish := options[r.rng.Intn(len(options))]
+	if r.available(dish) {
+		return dish, true
+	}
+	unavailable := Event{Type: evDishUnavailable, Party: p.ID, Table: p.Table.ID, Waiter: waiterID, Dish: dish.Name}
+	if r.disabled[dish.Name] {
+		unavailable.Reason = "menu_off"
+	}
+	r.emit(unavailable)
+	var others []Dish
+	for _, d := range options {
+		if r.available(d) {
+			others = append(others, d)
+		}
+	}
+	if len(others) == 0 || r.rng.Float64() >= r.cfg.ReorderChance {
+		return Dish{}, false
+	}
+	return others[r.rng.Intn(len(others))], true
+}
+
+// fireCourse отправляет на кухню текущий курс заказа
+func (r *Restaurant) fireCourse(order *Order) {
+	now := r.clock.now
+	dishes := order.courses[order.course]
+	var longest time.Duration
+	for _, dish := range dishes {
+		if dish.avgCookTime() > longest {
+			longest = dish.avgCookTime()
+		}
+	}
+	order.started = 0
+	order.pending = len(dishes)
+	order.Due = now.Add(longest + dueSlack)
+	r.emit(Event{Type: evCourseFired, Order: order.OrderID, Table: order.TableID, Course: order.courseName()})
+	r.queueDishes(order, dishes)
+}
+
+// queueDishes ставит блюда заказа в очередь кухни
+func (r *Restaurant) queueDishes(order *Order, dishes []Dish) {
+	now := r.clock.now
+	for _, dish := range dishes {
+		ticket := kitchenTicket{
+			id:     int(atomic.AddInt32(&r.ticketIDCounter, 1)),
+			order:  order,
+			dish:   dish,
+			queued: now,
+		}
+		r.kitchen.Put(ticket)
+		r.emit(Event{Type: evDishQueued, Ticket: ticket.id, Order: order.OrderID, Dish: dish.Name,
+			Station: dish.Station, Queue: r.kitchen.queued(dish.Station)})
+	}
+}
+
+// expectedCheck оценивает упущенную выручку компании, ушедшей до заказа
+func expectedCheck(menu []Dish, size int) float64 {
+	var total float64
+	for _, d := range menu {
+		total += d.BasePrice
+	}
+	return float64(size) * total / float64(len(menu))
+}
+
+// === Поток гостей ===
+
+func simulateCustomers(r *Restaurant, numTables int) {
+	lastSeating := r.closeTime.Add(-lastOrdersBeforeClose)
+	tickVirtual := time.Hour
+
+	for r.clock.now.Before(lastSeating) && !r.interrupted {
+		numPeople := r.cfg.Arrivals.guests(r.rng, numTables)
+
+		r.logf("[%s] В ближайший час ожидается %d новых клиентов\n", formatTime(r.clock.now), numPeople)
+		if numPeople == 0 {
+			r.pause(tickVirtual)
+			continue
+		}
+
+		// разбиваем гостей на компании и распределяем их приход по часу
+		var sizes []int
+		for numPeople > 0 {
+			size := partySizes[r.rng.Intn(len(partySizes))]
+			if size > numPeople {
+				size = numPeople
+			}
+			sizes = append(sizes, size)
+			numPeople -= size
+		}
+
+		gap := tickVirtual / time.Duration(len(sizes))
+		for _, size := range sizes {
+			if !r.clock.now.Before(lastSeating) || r.interrupted {
+				break
+			}
+			p := &Party{
+				ID:       int(atomic.AddInt32(&r.partyIDCounter, 1)),
+				Size:     size,
+				Patience: r.patience.sample(r.rng),
+				Arrived:  r.clock.now,
+				cond:     simCond{clock: r.clock},
+			}
+			r.clock.Go(func() { r.party(p) })
+			r.pause(gap)
+		}
+	}
+}
+
+// host перестаёт сажать гостей перед закрытием (или сразу, если смену
+// прервали) и закрывает кухню, когда зал опустел
+func host(r *Restaurant) {
+	r.pause(r.closeTime.Add(-lastOrdersBeforeClose).Sub(r.clock.now))
+	r.logf("[%s] Последние посадки — гостей больше не принимаем\n", formatTime(r.clock.now))
+	r.emit(Event{Type: evDoorsClosed})
+	r.closeDoors()
+
+	// заказы навынос и с доставкой, отданные гостям, тоже будят хоста через floor.empty
+	for r.floor.busy() || r.offsiteOpen > 0 {
+		r.floor.empty.Wait()
+	}
+	r.logf("[%s] Зал пуст, смена завершается\n", formatTime(r.clock.now))
+	r.emit(Event{Type: evKitchenClosed})
+	r.kitchen.Close()
+	r.floor.tasks.Close()
+	for _, m := range r.waiters {
+		m.tasks.Close()
+	}
+	r.couriers.Close()
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// === Виртуальные часы ===
+// Все участники симуляции (гости, официанты, повара) — горутины, которые
+// работают по общим виртуальным часам. В каждый момент выполняется только одна
+// из них, а время перескакивает к ближайшему запланированному событию.
+
+// simulationSpeed — во сколько раз виртуальное время идёт быстрее реального
+const simulationSpeed = 660
+
+type simProc struct {
+	wake chan bool
+	gen  int
+}
+
+type simEvent struct {
+	at      time.Time
+	seq     int64
+	proc    *simProc
+	gen     int
+	timeout bool
+}
+
+type simEvents []simEvent
+
+func (h simEvents) Len() int { return len(h) }
+func (h simEvents) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h simEvents) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *simEvents) Push(x any)   { *h = append(*h, x.(simEvent)) }
+func (h *simEvents) Pop() any {
+	old := *h
+	n := len(old)
+	ev := old[n-1]
+	*h = old[:n-1]
+	return ev
+}
+
+type simClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	realtime bool
+	events   simEvents
+	seq      int64
+	cur      *simProc
+	done     chan struct{}
+
+	cancelled <-chan struct{} // nil — отмена уже обработана или не ожидается
+	onCancel  func()
+}
+
+func newSimClock(start time.Time, realtime bool) *simClock {
+	return &simClock{now: start, realtime: realtime, done: make(chan struct{})}
+}
+
+func (c *simClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *simClock) schedule(p *simProc, at time.Time, timeout bool) {
+	c.seq++
+	heap.Push(&c.events, simEvent{at: at, seq: c.seq, proc: p, gen: p.gen, timeout: timeout})
+}
+
+// Go запускает новый процесс симуляции в текущий виртуальный момент
+func (c *simClock) Go(fn func()) {
+	p := &simProc{wake: make(chan bool, 1)}
+	c.schedule(p, c.now, false)
+	go func() {
+		<-p.wake
+		fn()
+		c.next()
+	}()
+}
+
+// next передаёт управление процессу с ближайшим событием
+func (c *simClock) next() {
+	for c.events.Len() > 0 {
+		select {
+		case <-c.cancelled:
+			c.cancel()
+		default:
+		}
+		ev := c.events[0]
+		if ev.gen != ev.proc.gen {
+			heap.Pop(&c.events)
+			continue
+		}
+		if c.realtime && ev.at.After(c.now) && !c.wait(toRealDuration(ev.at.Sub(c.now))) {
+			continue
+		}
+		heap.Pop(&c.events)
+		c.mu.Lock()
+		if ev.at.After(c.now) {
+			c.now = ev.at
+		}
+		c.mu.Unlock()
+
+		ev.proc.gen++
+		c.cur = ev.proc
+		ev.proc.wake <- ev.timeout
+		return
+	}
+	c.cur = nil
+	close(c.done)
+}
+
+func (c *simClock) park() bool {
+	p := c.cur
+	c.next()
+	return <-p.wake
+}
+
+func (c *simClock) Sleep(d time.Duration) {
+	c.schedule(c.cur, c.now.Add(d), true)
+	c.park()
+}
+
+func (c *simClock) SleepUntil(t time.Time) {
+	if t.After(c.now) {
+		c.Sleep(t.Sub(c.now))
+	}
+}
+
+// wait выдерживает паузу в реальном времени; false — если её прервала отмена
+func (c *simClock) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.cancelled:
+		return false
+	}
+}
+
+// cancel обрабатывает отмену один раз: дальше часы идут без привязки
+// к реальному времени, чтобы смена быстро доработала до конца
+func (c *simClock) cancel() {
+	c.cancelled = nil
+	c.realtime = false
+	if c.onCancel != nil {
+		c.onCancel()
+	}
+}
+
+// Run выполняет симуляцию, пока остаются запланированные события.
+// Отмена ctx не обрывает смену: onCancel вызывается в текущий виртуальный
+// момент и может только будить процессы, но не ждать сам
+func (c *simClock) Run(ctx context.Context, onCancel func()) {
+	c.cancelled = ctx.Done()
+	c.onCancel = onCancel
+	c.next()
+	<-c.done
+}
+
+type simWaiter struct {
+	proc *simProc
+	gen  int
+}
+
+type simCond struct {
+	clock   *simClock
+	waiters []simWaiter
+}
+
+func (q *simCond) Wait() {
+	p := q.clock.cur
+	q.waiters = append(q.waiters, simWaiter{p, p.gen})
+	q.clock.park()
+}
+
+// WaitTimeout ждёт сигнала не дольше d; false — если время вышло
+func (q *simCond) WaitTimeout(d time.Duration) bool {
+	p := q.clock.cur
+	q.waiters = append(q.waiters, simWaiter{p, p.gen})
+	q.clock.schedule(p, q.clock.now.Add(d), true)
+	return !q.clock.park()
+}
+
+func (q *simCond) Signal() {
+	for len(q.waiters) > 0 {
+		w := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		if w.gen == w.proc.gen {
+			q.clock.schedule(w.proc, q.clock.now, false)
+			return
+		}
+	}
+}
+
+func (q *simCond) Broadcast() {
+	for _, w := range q.waiters {
+		if w.gen == w.proc.gen {
+			q.clock.schedule(w.proc, q.clock.now, false)
+		}
+	}
+	q.waiters = nil
+}
+
+type waitResult int
+
+const (
+	waitOK waitResult = iota
+	waitTimeout
+	waitClosed
+)
+
+type simQueue[T any] struct {
+	items  []T
+	closed bool
+	cond   simCond
+}
+
+func newSimQueue[T any](c *simClock) *simQueue[T] {
+	return &simQueue[T]{cond: simCond{clock: c}}
+}
+
+func (q *simQueue[T]) Put(x T) {
+	q.items = append(q.items, x)
+	q.cond.Signal()
+}
+
+func (q *simQueue[T]) Get() (T, bool) {
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	var x T
+	if len(q.items) == 0 {
+		return x, false
+	}
+	x = q.items[0]
+	q.items = q.items[1:]
+	return x, true
+}
+
+// GetUntil ждёт элемент не дольше deadline; нулевой deadline — без ограничения
+func (q *simQueue[T]) GetUntil(deadline time.Time) (T, waitResult) {
+	var x T
+	for len(q.items) == 0 && !q.closed {
+		if deadline.IsZero() {
+			q.cond.Wait()
+		} else if !deadline.After(q.cond.clock.now) || !q.cond.WaitTimeout(deadline.Sub(q.cond.clock.now)) {
+			return x, waitTimeout
+		}
+	}
+	if len(q.items) == 0 {
+		return x, waitClosed
+	}
+	x = q.items[0]
+	q.items = q.items[1:]
+	return x, waitOK
+}
+
+func (q *simQueue[T]) Len() int { return len(q.items) }
+
+func (q *simQueue[T]) Close() {
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// getVirtualOpenCloseTimes кладёт смену на день day (нулевой — сегодня по UTC)
+func getVirtualOpenCloseTimes(day time.Time, openAt, duration time.Duration) (time.Time, time.Time) {
+	if day.IsZero() {
+		day = time.Now()
+	}
+	day = day.UTC()
+
+	openTime := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC).Add(openAt)
+	closeTime := openTime.Add(duration)
+
+	return openTime, closeTime
+}
+
+func toRealDuration(virtual time.Duration) time.Duration {
+	return virtual / time.Duration(simulationSpeed)
+}
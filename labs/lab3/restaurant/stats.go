@@ -0,0 +1,1122 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// === Статистика ===
+
+type TableStats struct {
+	Capacity     int
+	OrdersCount  int
+	TotalRevenue float64
+	TotalTime    time.Duration
+	Turns        int
+	OccupiedTime time.Duration
+	WaitToSeat   time.Duration
+	Abandoned    int
+	LostRevenue  float64
+}
+
+// DishStats — порции блюда в поданных заказах
+type DishStats struct {
+	Portions int
+	Revenue  float64
+}
+
+type StationStats struct {
+	Capacity  int
+	Dishes    int
+	BusyTime  time.Duration
+	QueueTime time.Duration
+	MaxQueue  int
+}
+
+type SeatingStats struct {
+	PartiesArrived    int
+	PartiesSeated     int
+	PartiesTurnedAway int // не пустили: закрытие или нет подходящего стола
+	PartiesWalkedOut  int // не дождались стола
+	OrdersAbandoned   int // ушли из-за стола, не дождавшись заказа
+	LeftSoldOut       int // из них ушли, потому что заказанного не оказалось в наличии
+	GuestsArrived     int
+	GuestsSeated      int
+	TotalWait         time.Duration
+	MaxQueue          int
+	LostRevenue       float64
+}
+
+// IngredientStats — движение продукта за день
+type IngredientStats struct {
+	Used     float64
+	Wasted   float64   // ушло на блюда, которые гости так и не получили
+	Shortage float64   // не хватило на порции, которые просили гости
+	OutAt    time.Time // когда из-за него впервые сняли блюдо с продажи
+}
+
+// CourseStats — подача одного курса: сколько ели и сколько ждали его после
+// предыдущего
+type CourseStats struct {
+	Served int
+	Eat    []time.Duration
+	Gaps   []time.Duration
+}
+
+// OffsiteStats — заказы одного канала: навынос или с доставкой
+type OffsiteStats struct {
+	Orders  int
+	Refused int // не приняли: всё меню в стоп-листе
+	Handed  int
+	Revenue float64
+	OnTime  int
+	Lead    []time.Duration // от заказа до того, как гость его получил
+	Late    []time.Duration // на сколько опоздали опоздавшие
+}
+
+// ZoneStats — доставки в район; дорога — от выезда курьера до двери
+type ZoneStats struct {
+	Deliveries int
+	OnTime     int
+	Travel     []time.Duration
+}
+
+// CourierStats — занятость курьера в часы работы, от выезда до возвращения
+type CourierStats struct {
+	Deliveries int
+	BusyTime   time.Duration
+}
+
+// offsiteInfo — заказ навынос или с доставкой, который ещё не отдали
+type offsiteInfo struct {
+	Channel  string
+	Zone     string
+	Dishes   []string
+	Price    float64
+	Ordered  time.Time
+	Promised time.Time
+	Departed time.Time
+}
+
+// ReservationStats — брони за день
+type ReservationStats struct {
+	Booked       int
+	Declined     int // не приняли: все подходящие столы уже заняты бронями
+	Arrived      int
+	LateArrivals int
+	Late         time.Duration // суммарное опоздание опоздавших
+	NoShows      int
+	ReleasedLate int // стол отпустили, не дождавшись опоздавших
+	Seated       int
+	SeatWait     time.Duration
+	WalkedOut    int
+	Conflicts    int           // к приходу гостей их стол ещё занят или свободного официанта нет
+	HeldIdle     time.Duration // стол придержан, а за ним никто не сидит
+}
+
+// bookingInfo — бронь из книги и что с ней стало
+type bookingInfo struct {
+	Size        int
+	Slot        time.Time
+	Table       int
+	Status      string
+	Held        time.Time // с какого момента держат стол; нулевое — не держат
+	Arrived     time.Time
+	Seated      time.Time
+	SeatedTable int
+}
+
+// mealInfo — когда заказу подали и когда доели последний курс
+type mealInfo struct {
+	Served time.Time
+	Eaten  time.Time
+}
+
+// BillingStats — итоги кассы по оплаченным счетам
+type BillingStats struct {
+	Checks    int
+	Split     int // счета, оплаченные по частям
+	Gross     float64
+	Discounts float64
+	Promos    map[string]float64 // скидки по акциям
+	Service   float64
+	VAT       float64
+	Total     float64
+	Tips      float64
+	Payments  map[string]*PaymentStats
+}
+
+type PaymentStats struct {
+	Count  int
+	Amount float64
+	Tips   float64
+}
+
+// CheckRecord — оплаченный счёт
+type CheckRecord struct {
+	Party  int
+	Table  int
+	Waiter int
+	Guests int
+	Paid   time.Time
+	Check  *Check
+}
+
+type ServeStats struct {
+	Count    int
+	Total    time.Duration
+	Max      time.Duration
+	VIPCount int
+	VIPTotal time.Duration
+}
+
+// OrderRecord — поданный заказ со всеми отметками времени
+type OrderRecord struct {
+	OrderID   int
+	TableID   int
+	WaiterID  int
+	Dishes    []string
+	Price     float64
+	Seated    time.Time
+	Ordered   time.Time
+	Ready     time.Time
+	Delivered time.Time
+}
+
+func (o OrderRecord) WaitToOrder() time.Duration  { return o.Ordered.Sub(o.Seated) }
+func (o OrderRecord) KitchenTime() time.Duration  { return o.Ready.Sub(o.Ordered) }
+func (o OrderRecord) DeliveryTime() time.Duration { return o.Delivered.Sub(o.Ready) }
+func (o OrderRecord) TotalTime() time.Duration    { return o.Delivered.Sub(o.Seated) }
+
+type staffKey struct {
+	Role string // chef или waiter
+	ID   int
+}
+
+type StaffStats struct {
+	Orders    int // поданные заказы, которые сотрудник принял
+	Dishes    int
+	Revenue   float64
+	Breaks    int
+	BreakTime time.Duration
+
+	Taken      int             // официант: принятые заказы
+	Deliveries int             // официант: заказы, которые он отнёс на стол
+	Delivery   []time.Duration // официант: от готовности заказа до подачи
+	BusyTime   time.Duration   // повар у плиты, официант занят делом
+	DutyTime   time.Duration   // на смене без учёта перерывов
+
+	Parties    int // официант: компании, закреплённые за ним при посадке
+	Covered    int // официант: столы, принятые от ушедших коллег
+	PeakTables int // официант: больше всего столов сразу
+
+	tables map[int]bool // столы, которые обслуживал официант
+	active int          // столы, закреплённые за официантом сейчас
+}
+
+// IdleTime — время на смене без дела
+func (st *StaffStats) IdleTime() time.Duration {
+	if st.BusyTime > st.DutyTime {
+		return 0
+	}
+	return st.DutyTime - st.BusyTime
+}
+
+// Utilization — доля времени на смене, занятая делом, в процентах
+func (st *StaffStats) Utilization() float64 {
+	return percentOf(st.BusyTime.Seconds(), st.DutyTime.Seconds())
+}
+
+// coverage — сколько сотрудников роли сейчас на месте и когда их не было никого
+type coverage struct {
+	onDuty int
+	since  time.Time // начало текущего провала, пока onDuty == 0
+	gaps   []gap
+}
+
+type gap struct {
+	From time.Time
+	To   time.Time
+}
+
+// HourStats группирует компании по часу прихода
+type HourStats struct {
+	PartiesArrived int
+	WalkedOut      int
+	Abandoned      int
+	Revenue        float64
+	LostRevenue    float64
+}
+
+type partyInfo struct {
+	Size    int
+	Arrived time.Time
+	Seated  time.Time
+	Paid    bool
+	Booking int
+	Waiter  int // официант, за которым закреплён стол; 0 — общий зал
+}
+
+type orderInfo struct {
+	Party   int
+	Table   int
+	Waiter  int
+	Dishes  []string
+	Price   float64
+	VIP     bool
+	Ordered time.Time
+	Ready   time.Time
+}
+
+// incidentSpan — когда происшествие на самом деле началось и закончилось
+type incidentSpan struct {
+	Started time.Time
+	Ended   time.Time // нулевое — длилось до конца дня
+}
+
+// lossRecord — компания, ушедшая ни с чем, по времени прихода
+type lossRecord struct {
+	Arrived time.Time
+	Amount  float64
+}
+
+type ticketInfo struct {
+	Queued  time.Time
+	Started time.Time
+}
+
+// Stats собирает статистику из событий симуляции. Меняет её только apply
+// под s.mu; печать, выгрузка и серии прогонов читают не сами Stats, а снимок
+// из Snapshot, поэтому не задерживают симуляцию и видят согласованные цифры.
+type Stats struct {
+	mu sync.Mutex
+
+	run          RunInfo
+	interrupted  time.Time // когда смену прервали; нулевое — отработала до конца
+	tableStats   map[int]*TableStats
+	dishStats    map[string]*DishStats
+	seating      SeatingStats
+	serve        ServeStats
+	billing      BillingStats
+	orders       []OrderRecord
+	checks       []CheckRecord
+	staffStats   map[staffKey]*StaffStats
+	dishLatency  map[string][]time.Duration // от заказа до готовности блюда
+	hourStats    map[int]*HourStats
+	stationStats map[string]*StationStats
+
+	recipes     map[string]map[string]float64
+	ingredients map[string]*IngredientStats
+	refused     map[string]int       // сколько раз просили блюдо, которого нет
+	soldOutAt   map[string]time.Time // когда блюдо впервые сняли с продажи
+	shortOf     map[string]string    // продукт, из-за которого блюда нет
+
+	courseStats map[string]*CourseStats
+	meals       map[int]*mealInfo
+	dwell       []time.Duration // от посадки до ухода у компаний, оплативших счёт
+
+	offsite      map[string]*OffsiteStats
+	zones        map[string]*ZoneStats
+	couriers     map[int]*CourierStats
+	offsiteOpen  map[int]*offsiteInfo
+	courierSince map[int]time.Time // когда курьер уехал в текущую поездку
+
+	reservations ReservationStats
+	bookings     map[int]*bookingInfo
+
+	incidents map[int]*incidentSpan
+	arrivals  []time.Time  // приход компаний в зал
+	losses    []lossRecord // компании, которых зал потерял
+
+	coverage map[string]*coverage
+
+	// незавершённые сущности, нужные для расчёта длительностей
+	parties map[int]*partyInfo
+	pending map[int]*orderInfo
+	tickets map[int]*ticketInfo
+	breaks  map[staffKey]time.Time
+	onDuty  map[staffKey]time.Time // начало текущего отрезка работы
+}
+
+func newStats() *Stats {
+	return &Stats{
+		tableStats:   make(map[int]*TableStats),
+		dishStats:    make(map[string]*DishStats),
+		staffStats:   make(map[staffKey]*StaffStats),
+		dishLatency:  make(map[string][]time.Duration),
+		hourStats:    make(map[int]*HourStats),
+		stationStats: make(map[string]*StationStats),
+		parties:      make(map[int]*partyInfo),
+		pending:      make(map[int]*orderInfo),
+		tickets:      make(map[int]*ticketInfo),
+		coverage:     make(map[string]*coverage),
+		breaks:       make(map[staffKey]time.Time),
+		onDuty:       make(map[staffKey]time.Time),
+		recipes:      make(map[string]map[string]float64),
+		ingredients:  make(map[string]*IngredientStats),
+		refused:      make(map[string]int),
+		soldOutAt:    make(map[string]time.Time),
+		shortOf:      make(map[string]string),
+		courseStats:  make(map[string]*CourseStats),
+		meals:        make(map[int]*mealInfo),
+		offsite:      make(map[string]*OffsiteStats),
+		zones:        make(map[string]*ZoneStats),
+		couriers:     make(map[int]*CourierStats),
+		offsiteOpen:  make(map[int]*offsiteInfo),
+		courierSince: make(map[int]time.Time),
+		bookings:     make(map[int]*bookingInfo),
+		incidents:    make(map[int]*incidentSpan),
+		billing: BillingStats{
+			Promos:   make(map[string]float64),
+			Payments: make(map[string]*PaymentStats),
+		},
+	}
+}
+
+// StatsSnapshot — неизменяемая копия статистики на момент вызова Snapshot.
+// Методы отчётов и печати определены только на нём.
+type StatsSnapshot Stats
+
+// Snapshot копирует всю накопленную статистику под замком; дальнейшие
+// события на копию не влияют
+func (s *Stats) Snapshot() *StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := &StatsSnapshot{
+		run:          s.run,
+		interrupted:  s.interrupted,
+		tableStats:   clonePointers(s.tableStats),
+		dishStats:    clonePointers(s.dishStats),
+		seating:      s.seating,
+		serve:        s.serve,
+		billing:      s.billing,
+		orders:       append([]OrderRecord(nil), s.orders...),
+		checks:       append([]CheckRecord(nil), s.checks...),
+		staffStats:   make(map[staffKey]*StaffStats, len(s.staffStats)),
+		dishLatency:  make(map[string][]time.Duration, len(s.dishLatency)),
+		hourStats:    clonePointers(s.hourStats),
+		stationStats: clonePointers(s.stationStats),
+		recipes:      cloneValues(s.recipes), // рецепты не меняются после начала смены
+		ingredients:  clonePointers(s.ingredients),
+		refused:      cloneValues(s.refused),
+		soldOutAt:    cloneValues(s.soldOutAt),
+		shortOf:      cloneValues(s.shortOf),
+		courseStats:  make(map[string]*CourseStats, len(s.courseStats)),
+		meals:        clonePointers(s.meals),
+		dwell:        append([]time.Duration(nil), s.dwell...),
+		offsite:      make(map[string]*OffsiteStats, len(s.offsite)),
+		zones:        make(map[string]*ZoneStats, len(s.zones)),
+		couriers:     clonePointers(s.couriers),
+		offsiteOpen:  clonePointers(s.offsiteOpen),
+		courierSince: cloneValues(s.courierSince),
+		reservations: s.reservations,
+		bookings:     clonePointers(s.bookings),
+		incidents:    clonePointers(s.incidents),
+		arrivals:     append([]time.Time(nil), s.arrivals...),
+		losses:       append([]lossRecord(nil), s.losses...),
+		coverage:     make(map[string]*coverage, len(s.coverage)),
+		parties:      clonePointers(s.parties),
+		pending:      clonePointers(s.pending),
+		tickets:      clonePointers(s.tickets),
+		breaks:       cloneValues(s.breaks),
+		onDuty:       cloneValues(s.onDuty),
+	}
+	snap.billing.Promos = cloneValues(s.billing.Promos)
+	snap.billing.Payments = clonePointers(s.billing.Payments)
+	for key, st := range s.staffStats {
+		c := *st
+		c.Delivery = append([]time.Duration(nil), st.Delivery...)
+		c.tables = cloneValues(st.tables)
+		snap.staffStats[key] = &c
+	}
+	for dish, xs := range s.dishLatency {
+		snap.dishLatency[dish] = append([]time.Duration(nil), xs...)
+	}
+	for name, st := range s.courseStats {
+		c := *st
+		c.Eat = append([]time.Duration(nil), st.Eat...)
+		c.Gaps = append([]time.Duration(nil), st.Gaps...)
+		snap.courseStats[name] = &c
+	}
+	for role, cov := range s.coverage {
+		c := *cov
+		c.gaps = append([]gap(nil), cov.gaps...)
+		snap.coverage[role] = &c
+	}
+	for channel, st := range s.offsite {
+		c := *st
+		c.Lead = append([]time.Duration(nil), st.Lead...)
+		c.Late = append([]time.Duration(nil), st.Late...)
+		snap.offsite[channel] = &c
+	}
+	for name, st := range s.zones {
+		c := *st
+		c.Travel = append([]time.Duration(nil), st.Travel...)
+		snap.zones[name] = &c
+	}
+	return snap
+}
+
+func cloneValues[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// clonePointers копирует карту вместе со значениями, на которые она указывает
+func clonePointers[K comparable, V any](m map[K]*V) map[K]*V {
+	out := make(map[K]*V, len(m))
+	for k, v := range m {
+		c := *v
+		out[k] = &c
+	}
+	return out
+}
+
+// eventStaff — сотрудник, к которому относится событие смены
+func eventStaff(ev Event) staffKey {
+	if ev.Chef != 0 {
+		return staffKey{"chef", ev.Chef}
+	}
+	return staffKey{"waiter", ev.Waiter}
+}
+
+// present учитывает приход (delta > 0) или уход сотрудника роли role
+func (s *Stats) present(role string, delta int, t time.Time) {
+	c, ok := s.coverage[role]
+	if !ok {
+		c = &coverage{since: s.run.Open}
+		s.coverage[role] = c
+	}
+	if c.onDuty == 0 && delta > 0 {
+		s.addGap(c, c.since, t)
+	}
+	c.onDuty += delta
+	if c.onDuty == 0 {
+		c.since = t
+	}
+}
+
+// addGap запоминает провал покрытия в пределах часов работы
+func (s *Stats) addGap(c *coverage, from, to time.Time) {
+	if from.Before(s.run.Open) {
+		from = s.run.Open
+	}
+	if to.After(s.run.Close) {
+		to = s.run.Close
+	}
+	if to.After(from) {
+		c.gaps = append(c.gaps, gap{from, to})
+	}
+}
+
+// clockIn и clockOut отмечают начало и конец отрезка работы: смены или её
+// части между перерывами
+func (s *Stats) clockIn(key staffKey, t time.Time) {
+	s.onDuty[key] = t
+}
+
+func (s *Stats) clockOut(key staffKey, t time.Time) {
+	since, ok := s.onDuty[key]
+	if !ok {
+		return
+	}
+	s.staff(key.Role, key.ID).DutyTime += t.Sub(since)
+	delete(s.onDuty, key)
+}
+
+func (s *Stats) table(tableID int) *TableStats {
+	stats, exists := s.tableStats[tableID]
+	if !exists {
+		stats = &TableStats{}
+		s.tableStats[tableID] = stats
+	}
+	return stats
+}
+
+func (s *Stats) hour(t time.Time) *HourStats {
+	stats, exists := s.hourStats[t.Hour()]
+	if !exists {
+		stats = &HourStats{}
+		s.hourStats[t.Hour()] = stats
+	}
+	return stats
+}
+
+func (s *Stats) staff(role string, id int) *StaffStats {
+	key := staffKey{role, id}
+	stats, exists := s.staffStats[key]
+	if !exists {
+		stats = &StaffStats{}
+		s.staffStats[key] = stats
+	}
+	return stats
+}
+
+func (s *Stats) course(name string) *CourseStats {
+	stats, exists := s.courseStats[name]
+	if !exists {
+		stats = &CourseStats{}
+		s.courseStats[name] = stats
+	}
+	return stats
+}
+
+func (s *Stats) channel(name string) *OffsiteStats {
+	stats, exists := s.offsite[name]
+	if !exists {
+		stats = &OffsiteStats{}
+		s.offsite[name] = stats
+	}
+	return stats
+}
+
+func (s *Stats) zone(name string) *ZoneStats {
+	stats, exists := s.zones[name]
+	if !exists {
+		stats = &ZoneStats{}
+		s.zones[name] = stats
+	}
+	return stats
+}
+
+func (s *Stats) courier(id int) *CourierStats {
+	stats, exists := s.couriers[id]
+	if !exists {
+		stats = &CourierStats{}
+		s.couriers[id] = stats
+	}
+	return stats
+}
+
+// lose отмечает компанию, которую зал потерял
+func (s *Stats) lose(partyID int, amount float64) {
+	s.losses = append(s.losses, lossRecord{Arrived: s.party(partyID).Arrived, Amount: amount})
+}
+
+// attach отмечает ещё один стол, закреплённый за официантом
+func (s *Stats) attach(waiterID int) {
+	st := s.staff("waiter", waiterID)
+	st.active++
+	if st.active > st.PeakTables {
+		st.PeakTables = st.active
+	}
+}
+
+// unhold закрывает удержание стола под бронь
+func (s *Stats) unhold(b *bookingInfo, t time.Time) {
+	if !b.Held.IsZero() {
+		s.reservations.HeldIdle += t.Sub(b.Held)
+		b.Held = time.Time{}
+	}
+}
+
+// sell учитывает порции блюд, отданных гостям, по ценам меню
+func (s *Stats) sell(names []string) {
+	for _, name := range names {
+		dish := s.dishStats[name]
+		if dish == nil {
+			dish = &DishStats{}
+			s.dishStats[name] = dish
+		}
+		dish.Portions++
+		dish.Revenue += s.dishPrice(name)
+	}
+}
+
+func (s *Stats) party(id int) *partyInfo {
+	p, exists := s.parties[id]
+	if !exists {
+		p = &partyInfo{}
+		s.parties[id] = p
+	}
+	return p
+}
+
+// dishPrice — цена блюда по меню смены
+func (s *Stats) dishPrice(name string) float64 {
+	for _, d := range s.run.Menu {
+		if d.Name == name {
+			return d.BasePrice
+		}
+	}
+	return 0
+}
+
+// clipToOpen возвращает часть интервала, пришедшуюся на часы работы ресторана;
+// по ней считается загрузка столов и станций
+func (s *Stats) clipToOpen(from, to time.Time) time.Duration {
+	if from.Before(s.run.Open) {
+		from = s.run.Open
+	}
+	if to.After(s.run.Close) {
+		to = s.run.Close
+	}
+	if !to.After(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+func (s *Stats) apply(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ev.Waiter != 0 && ev.Busy > 0 {
+		waiter := s.staff("waiter", ev.Waiter)
+		waiter.BusyTime += ev.Busy
+		if waiter.tables == nil {
+			waiter.tables = make(map[int]bool)
+		}
+		waiter.tables[ev.Table] = true
+	}
+
+	switch ev.Type {
+	case evRunStarted:
+		s.run = *ev.Run
+		for _, t := range s.run.Tables {
+			s.table(t.ID).Capacity = t.Capacity
+		}
+		for _, st := range s.run.Stations {
+			s.stationStats[st.Name] = &StationStats{Capacity: st.Capacity}
+		}
+		for _, d := range s.run.Menu {
+			s.recipes[d.Name] = d.Recipe
+		}
+		for _, ing := range s.run.Ingredients {
+			s.ingredients[ing.Name] = &IngredientStats{}
+		}
+		for _, m := range s.run.Staff {
+			s.staff(m.Role, m.ID)
+			if _, ok := s.coverage[m.Role]; !ok {
+				s.coverage[m.Role] = &coverage{since: s.run.Open}
+			}
+		}
+
+	case evShiftStarted:
+		key := eventStaff(ev)
+		s.clockIn(key, ev.Time)
+		s.present(key.Role, 1, ev.Time)
+
+	case evShiftEnded:
+		key := eventStaff(ev)
+		s.clockOut(key, ev.Time)
+		s.present(key.Role, -1, ev.Time)
+
+	case evBreakStarted:
+		key := eventStaff(ev)
+		s.breaks[key] = ev.Time
+		s.clockOut(key, ev.Time)
+		s.present(key.Role, -1, ev.Time)
+
+	case evBreakEnded:
+		key := eventStaff(ev)
+		stats := s.staff(key.Role, key.ID)
+		stats.Breaks++
+		stats.BreakTime += ev.Time.Sub(s.breaks[key])
+		delete(s.breaks, key)
+		s.clockIn(key, ev.Time)
+		s.present(key.Role, 1, ev.Time)
+
+	case evRunInterrupted:
+		s.interrupted = ev.Time
+
+	case evRunFinished:
+		for key := range s.onDuty {
+			s.clockOut(key, ev.Time)
+		}
+		for _, c := range s.coverage {
+			if c.onDuty == 0 {
+				s.addGap(c, c.since, ev.Time)
+			}
+		}
+
+	case evPartyArrived:
+		s.party(ev.Party).Size = ev.Size
+		s.party(ev.Party).Arrived = ev.Time
+		s.seating.PartiesArrived++
+		s.seating.GuestsArrived += ev.Size
+		s.hour(ev.Time).PartiesArrived++
+		s.arrivals = append(s.arrivals, ev.Time)
+		if b, ok := s.bookings[ev.Booking]; ok {
+			s.party(ev.Party).Booking = ev.Booking
+			b.Arrived = ev.Time
+			s.reservations.Arrived++
+			if late := ev.Time.Sub(b.Slot); late > 0 {
+				s.reservations.LateArrivals++
+				s.reservations.Late += late
+			}
+		}
+
+	case evPartyQueued:
+		if ev.Queue > s.seating.MaxQueue {
+			s.seating.MaxQueue = ev.Queue
+		}
+
+	case evPartyTurnedAway:
+		s.seating.PartiesTurnedAway++
+		s.lose(ev.Party, 0)
+		if b, ok := s.bookings[s.party(ev.Party).Booking]; ok {
+			b.Status = "turned_away"
+		}
+		delete(s.parties, ev.Party)
+
+	case evPartyWalkedOut:
+		s.seating.PartiesWalkedOut++
+		s.lose(ev.Party, ev.Amount)
+		s.seating.LostRevenue += ev.Amount
+		hour := s.hour(s.party(ev.Party).Arrived)
+		hour.WalkedOut++
+		hour.LostRevenue += ev.Amount
+		if b, ok := s.bookings[s.party(ev.Party).Booking]; ok {
+			b.Status = "walked_out"
+			s.reservations.WalkedOut++
+		}
+		delete(s.parties, ev.Party)
+
+	case evPartySeated:
+		p := s.party(ev.Party)
+		p.Seated = ev.Time
+		wait := ev.Time.Sub(p.Arrived)
+		s.seating.PartiesSeated++
+		s.seating.GuestsSeated += p.Size
+		s.seating.TotalWait += wait
+
+		stats := s.table(ev.Table)
+		stats.Turns++
+		stats.WaitToSeat += wait
+
+		if ev.Waiter != 0 {
+			p.Waiter = ev.Waiter
+			s.staff("waiter", ev.Waiter).Parties++
+			s.attach(ev.Waiter)
+		}
+
+		if b, ok := s.bookings[ev.Booking]; ok {
+			s.reservations.Seated++
+			s.reservations.SeatWait += wait
+			s.unhold(b, ev.Time)
+			b.Status = "seated"
+			b.Seated = ev.Time
+			b.SeatedTable = ev.Table
+		}
+
+	case evOrderPlaced:
+		s.staff("waiter", ev.Waiter).Taken++
+		s.pending[ev.Order] = &orderInfo{
+			Party:   ev.Party,
+			Table:   ev.Table,
+			Waiter:  ev.Waiter,
+			Dishes:  ev.Dishes,
+			Price:   ev.Amount,
+			VIP:     ev.VIP,
+			Ordered: ev.Time,
+		}
+
+	case evDishQueued:
+		s.tickets[ev.Ticket] = &ticketInfo{Queued: ev.Time}
+		if stats, ok := s.stationStats[ev.Station]; ok && ev.Queue > stats.MaxQueue {
+			stats.MaxQueue = ev.Queue
+		}
+
+	case evDishDiscarded:
+		delete(s.tickets, ev.Ticket)
+
+	case evCookingStarted:
+		if t, ok := s.tickets[ev.Ticket]; ok {
+			t.Started = ev.Time
+		}
+		for name, qty := range s.recipes[ev.Dish] {
+			if ing, ok := s.ingredients[name]; ok {
+				ing.Used += qty
+			}
+		}
+
+	case evStockOut:
+		if _, ok := s.soldOutAt[ev.Dish]; !ok {
+			s.soldOutAt[ev.Dish] = ev.Time
+		}
+		s.shortOf[ev.Dish] = ev.Ingredient
+		if ing, ok := s.ingredients[ev.Ingredient]; ok && ing.OutAt.IsZero() {
+			ing.OutAt = ev.Time
+		}
+
+	case evDishUnavailable:
+		s.refused[ev.Dish]++
+		if ing, ok := s.ingredients[s.shortOf[ev.Dish]]; ok && ev.Reason != "menu_off" {
+			ing.Shortage += s.recipes[ev.Dish][s.shortOf[ev.Dish]]
+		}
+
+	case evCookingFinished:
+		t, ok := s.tickets[ev.Ticket]
+		if !ok {
+			return
+		}
+		delete(s.tickets, ev.Ticket)
+		s.staff("chef", ev.Chef).BusyTime += ev.Time.Sub(t.Started)
+		if ev.Reason == "wasted" {
+			for name, qty := range s.recipes[ev.Dish] {
+				if ing, ok := s.ingredients[name]; ok {
+					ing.Wasted += qty
+				}
+			}
+		}
+		if stats, ok := s.stationStats[ev.Station]; ok {
+			stats.Dishes++
+			stats.QueueTime += t.Started.Sub(t.Queued)
+			stats.BusyTime += s.clipToOpen(t.Started, ev.Time)
+		}
+		if ev.Reason != "" {
+			return
+		}
+		chef := s.staff("chef", ev.Chef)
+		chef.Dishes++
+		chef.Revenue += ev.Amount
+		s.dishLatency[ev.Dish] = append(s.dishLatency[ev.Dish], ev.Time.Sub(t.Queued))
+
+	case evOrderReady:
+		if o, ok := s.pending[ev.Order]; ok {
+			o.Ready = ev.Time
+		}
+
+	case evOrderDelivered:
+		o, ok := s.pending[ev.Order]
+		if !ok {
+			return
+		}
+		delete(s.pending, ev.Order)
+		p := s.party(o.Party)
+		s.sell(o.Dishes)
+
+		duration := ev.Time.Sub(o.Ordered)
+		stats := s.table(o.Table)
+		stats.OrdersCount++
+		stats.TotalRevenue += o.Price
+		stats.TotalTime += duration
+		s.hour(p.Arrived).Revenue += o.Price
+
+		s.serve.Count++
+		s.serve.Total += duration
+		if duration > s.serve.Max {
+			s.serve.Max = duration
+		}
+		if o.VIP {
+			s.serve.VIPCount++
+			s.serve.VIPTotal += duration
+		}
+
+		s.orders = append(s.orders, OrderRecord{
+			OrderID:   ev.Order,
+			TableID:   o.Table,
+			WaiterID:  o.Waiter,
+			Dishes:    o.Dishes,
+			Price:     o.Price,
+			Seated:    p.Seated,
+			Ordered:   o.Ordered,
+			Ready:     o.Ready,
+			Delivered: ev.Time,
+		})
+
+		waiter := s.staff("waiter", o.Waiter)
+		waiter.Orders++
+		waiter.Revenue += o.Price
+
+		runner := s.staff("waiter", ev.Waiter)
+		runner.Deliveries++
+		runner.Delivery = append(runner.Delivery, ev.Time.Sub(o.Ready))
+
+	case evPartyAbandoned:
+		delete(s.pending, ev.Order)
+		s.seating.OrdersAbandoned++
+		s.lose(ev.Party, ev.Amount)
+		if ev.Reason == "sold_out" {
+			s.seating.LeftSoldOut++
+		}
+		s.seating.LostRevenue += ev.Amount
+
+		hour := s.hour(s.party(ev.Party).Arrived)
+		hour.Abandoned++
+		hour.LostRevenue += ev.Amount
+
+		stats := s.table(ev.Table)
+		stats.Abandoned++
+		stats.LostRevenue += ev.Amount
+
+	case evCourseServed:
+		stats := s.course(ev.Course)
+		stats.Served++
+		m, ok := s.meals[ev.Order]
+		if !ok {
+			m = &mealInfo{}
+			s.meals[ev.Order] = m
+		}
+		if !m.Eaten.IsZero() {
+			stats.Gaps = append(stats.Gaps, ev.Time.Sub(m.Eaten))
+		}
+		m.Served = ev.Time
+
+	case evCourseEaten:
+		if m, ok := s.meals[ev.Order]; ok {
+			s.course(ev.Course).Eat = append(s.course(ev.Course).Eat, ev.Time.Sub(m.Served))
+			m.Eaten = ev.Time
+		}
+
+	case evPartyLeft:
+		if p := s.party(ev.Party); p.Paid {
+			s.dwell = append(s.dwell, ev.Time.Sub(p.Seated))
+		}
+
+	case evBillPaid:
+		s.party(ev.Party).Paid = true
+		c := ev.Check
+		b := &s.billing
+		b.Checks++
+		if len(c.Payments) > 1 {
+			b.Split++
+		}
+		b.Gross += c.Gross
+		b.Discounts += c.Discounts
+		for _, line := range c.Lines {
+			if line.Promo != "" {
+				b.Promos[line.Promo] += line.Discount
+			}
+		}
+		b.Service += c.Service
+		b.VAT += c.VAT
+		b.Total += c.Total
+		b.Tips += c.Tip
+		for _, pay := range c.Payments {
+			ps, ok := b.Payments[pay.Method]
+			if !ok {
+				ps = &PaymentStats{}
+				b.Payments[pay.Method] = ps
+			}
+			ps.Count++
+			ps.Amount += pay.Amount
+			ps.Tips += pay.Tip
+		}
+		s.checks = append(s.checks, CheckRecord{
+			Party:  ev.Party,
+			Table:  ev.Table,
+			Waiter: ev.Waiter,
+			Guests: s.party(ev.Party).Size,
+			Paid:   ev.Time,
+			Check:  c,
+		})
+
+	case evTableCleared:
+		p := s.party(ev.Party)
+		stats := s.table(ev.Table)
+		stats.OccupiedTime += s.clipToOpen(p.Seated, ev.Time)
+		if p.Waiter != 0 {
+			s.staff("waiter", p.Waiter).active--
+		}
+		delete(s.parties, ev.Party)
+
+	case evIncidentStarted:
+		s.incidents[ev.Incident] = &incidentSpan{Started: ev.Time}
+
+	case evIncidentEnded:
+		if span, ok := s.incidents[ev.Incident]; ok {
+			span.Ended = ev.Time
+		}
+
+	case evTableReassigned:
+		p := s.party(ev.Party)
+		s.staff("waiter", p.Waiter).active--
+		p.Waiter = ev.Waiter
+		s.staff("waiter", ev.Waiter).Covered++
+		s.attach(ev.Waiter)
+
+	case evOffsiteOrdered:
+		s.channel(ev.Channel).Orders++
+		s.offsiteOpen[ev.Order] = &offsiteInfo{
+			Channel:  ev.Channel,
+			Zone:     ev.Zone,
+			Dishes:   ev.Dishes,
+			Price:    ev.Amount,
+			Ordered:  ev.Time,
+			Promised: *ev.Promised,
+		}
+
+	case evOffsiteRefused:
+		s.channel(ev.Channel).Refused++
+
+	case evCourierDeparted:
+		s.courierSince[ev.Courier] = ev.Time
+		if o, ok := s.offsiteOpen[ev.Order]; ok {
+			o.Departed = ev.Time
+		}
+
+	case evOffsiteHandedOver:
+		o, ok := s.offsiteOpen[ev.Order]
+		if !ok {
+			return
+		}
+		delete(s.offsiteOpen, ev.Order)
+		s.sell(o.Dishes)
+		stats := s.channel(o.Channel)
+		stats.Handed++
+		stats.Revenue += o.Price
+		stats.Lead = append(stats.Lead, ev.Time.Sub(o.Ordered))
+		late := ev.Time.Sub(o.Promised)
+		if late <= 0 {
+			stats.OnTime++
+		} else {
+			stats.Late = append(stats.Late, late)
+		}
+		if o.Channel == channelDelivery {
+			zone := s.zone(o.Zone)
+			zone.Deliveries++
+			if late <= 0 {
+				zone.OnTime++
+			}
+			zone.Travel = append(zone.Travel, ev.Time.Sub(o.Departed))
+			s.courier(ev.Courier).Deliveries++
+		}
+
+	case evReservationBooked, evReservationDeclined:
+		b := &bookingInfo{Size: ev.Size, Slot: *ev.Slot, Table: ev.Table, Status: "booked"}
+		s.bookings[ev.Booking] = b
+		if ev.Type == evReservationDeclined {
+			b.Status = "declined"
+			s.reservations.Declined++
+		} else {
+			s.reservations.Booked++
+		}
+
+	case evReservationHeld:
+		if b, ok := s.bookings[ev.Booking]; ok {
+			b.Held = ev.Time
+		}
+
+	case evReservationReleased:
+		b, ok := s.bookings[ev.Booking]
+		if !ok {
+			return
+		}
+		s.unhold(b, ev.Time)
+		switch ev.Reason {
+		case "no_show":
+			s.reservations.NoShows++
+			b.Status = "no_show"
+		case "late":
+			s.reservations.ReleasedLate++
+			b.Status = "released"
+		case "closing":
+			if b.Status == "booked" {
+				b.Status = "cancelled"
+			}
+		}
+
+	case evReservationConflict:
+		s.reservations.Conflicts++
+
+	case evCourierReturned:
+		s.courier(ev.Courier).BusyTime += s.clipToOpen(s.courierSince[ev.Courier], ev.Time)
+		delete(s.courierSince, ev.Courier)
+	}
+}
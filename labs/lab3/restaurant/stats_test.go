@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Читатели статистики (печать хода смены, веб-панель, выгрузка) работают
+// параллельно с симуляцией. Тест гоняет смены, пока несколько горутин без
+// остановки снимают Snapshot и строят по нему отчёт, и проверяет, что снимок
+// не меняется после того, как его сняли, счётчики от снимка к снимку не
+// убывают, а итог смены совпадает с прогоном без читателей. Смысл теста —
+// в запуске с детектором гонок:
+//
+//	GO111MODULE=off go test -race -run TestStatsConcurrentSnapshot .
+func TestStatsConcurrentSnapshot(t *testing.T) {
+	const readers = 4
+	runs := 10
+	if testing.Short() {
+		runs = 2
+	}
+	cfg, err := defaultScenario().config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Realtime = false
+	cfg.Quiet = true
+	for i := 1; i <= runs; i++ {
+		run := cfg
+		run.Seed = int64(i)
+		t.Run(fmt.Sprintf("seed=%d", run.Seed), func(t *testing.T) {
+			t.Parallel()
+			if err := stressShift(run, readers); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// stressShift проводит одну смену под читателями и сверяет итог с тихим прогоном
+func stressShift(cfg SimConfig, readers int) error {
+	want, err := reportJSON(simulate(cfg).Snapshot())
+	if err != nil {
+		return err
+	}
+
+	r := newRestaurant(cfg)
+	done := make(chan struct{})
+	errs := make(chan error, readers)
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- readStats(r.stats, done)
+		}()
+	}
+	r.run(context.Background())
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	got, err := reportJSON(r.stats.Snapshot())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return errors.New("итог смены зависит от читателей статистики")
+	}
+	return nil
+}
+
+// readStats снимает статистику, пока не закроют done
+func readStats(s *Stats, done <-chan struct{}) error {
+	var prev *StatsSnapshot
+	var prevJSON []byte
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		snap := s.Snapshot()
+		data, err := reportJSON(snap)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			again, err := reportJSON(prev)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(again, prevJSON) {
+				return errors.New("снимок статистики изменился после того, как его сняли")
+			}
+			if snap.seating.PartiesArrived < prev.seating.PartiesArrived || len(snap.orders) < len(prev.orders) ||
+				len(snap.checks) < len(prev.checks) {
+				return errors.New("счётчики в новом снимке меньше, чем в предыдущем")
+			}
+		}
+		prev, prevJSON = snap, data
+	}
+}
+
+func reportJSON(s *StatsSnapshot) ([]byte, error) {
+	data, err := json.Marshal(s.report())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось собрать отчёт: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// === Хронология кухни ===
+// Кто, что и когда готовил. go run . timeline events.jsonl [каталог]
+// строит по журналу событий диаграмму Ганта (timeline.svg и timeline.html
+// без внешних зависимостей) и trace.json для about:tracing и Perfetto.
+// Дорожки — повара и места на станциях, подписи — номера заказов.
+// run -timeline делает то же сразу после смены.
+
+const (
+	spanCook   = "cook"
+	spanBreak  = "break"
+	spanDuty   = "duty"   // повар на смене
+	spanOutage = "outage" // место на станции выключено происшествием
+)
+
+// timelineSpan — отрезок на дорожке хронологии
+type timelineSpan struct {
+	Kind       string
+	Start, End time.Time
+	Order      int
+	Ticket     int
+	Dish       string
+	Chef       int
+	Station    string
+	Slot       int  // место на станции, с 1
+	Wasted     bool // гости ушли, пока блюдо готовилось
+	Unfinished bool // журнал оборвался раньше, чем блюдо доготовили
+}
+
+type timelineLane struct {
+	Title   string
+	Chef    int    // дорожка повара
+	Station string // дорожка места на станции
+	Slot    int
+	Spans   []timelineSpan
+}
+
+// spanRef указывает на отрезок, который ещё не закончился
+type spanRef struct {
+	lane *timelineLane
+	i    int
+}
+
+func (ref spanRef) span() *timelineSpan {
+	return &ref.lane.Spans[ref.i]
+}
+
+type kitchenTimeline struct {
+	Open, Close time.Time
+	End         time.Time // последнее событие журнала
+	Seed        int64
+	Chefs       []*timelineLane
+	Stations    []*timelineLane
+}
+
+// buildTimeline раскладывает готовку, перерывы и поломки по дорожкам.
+// Место на станции блюдо получает первое свободное по порядку.
+func buildTimeline(events []Event) *kitchenTimeline {
+	tl := &kitchenTimeline{}
+	chefs := make(map[int]*timelineLane)
+	slots := make(map[string][]*timelineLane)
+	busy := make(map[string][]int)           // станция → билет на каждом месте, 0 — свободно
+	cooking := make(map[int][]spanRef)       // билет → отрезки у повара и на станции
+	open := make(map[int]map[string]spanRef) // повар → открытые смена и перерыв
+	planned := make(map[int]IncidentInfo)
+	outages := make(map[int][]spanRef)
+
+	chef := func(id int) *timelineLane {
+		lane, ok := chefs[id]
+		if !ok {
+			lane = &timelineLane{Title: fmt.Sprintf("Повар %d", id), Chef: id}
+			chefs[id] = lane
+			tl.Chefs = append(tl.Chefs, lane)
+		}
+		return lane
+	}
+	slot := func(station string, i int) *timelineLane {
+		for len(slots[station]) <= i {
+			n := len(slots[station]) + 1
+			lane := &timelineLane{Title: fmt.Sprintf("%s, место %d", station, n), Station: station, Slot: n}
+			slots[station] = append(slots[station], lane)
+			busy[station] = append(busy[station], 0)
+			tl.Stations = append(tl.Stations, lane)
+		}
+		return slots[station][i]
+	}
+	begin := func(lane *timelineLane, span timelineSpan) spanRef {
+		lane.Spans = append(lane.Spans, span)
+		return spanRef{lane, len(lane.Spans) - 1}
+	}
+
+	for _, ev := range events {
+		tl.End = ev.Time
+		switch ev.Type {
+		case evRunStarted:
+			tl.Open, tl.Close, tl.Seed = ev.Run.Open, ev.Run.Close, ev.Run.Seed
+			for _, m := range ev.Run.Staff {
+				if m.Role == "chef" {
+					chef(m.ID)
+				}
+			}
+			for _, st := range ev.Run.Stations {
+				slot(st.Name, st.Capacity-1)
+			}
+			for _, inc := range ev.Run.Incidents {
+				planned[inc.ID] = inc
+			}
+		case evShiftStarted, evBreakStarted:
+			if ev.Chef == 0 {
+				continue
+			}
+			kind := spanDuty
+			if ev.Type == evBreakStarted {
+				kind = spanBreak
+			}
+			if open[ev.Chef] == nil {
+				open[ev.Chef] = make(map[string]spanRef)
+			}
+			open[ev.Chef][kind] = begin(chef(ev.Chef), timelineSpan{Kind: kind, Start: ev.Time, Chef: ev.Chef})
+		case evShiftEnded, evBreakEnded:
+			kind := spanDuty
+			if ev.Type == evBreakEnded {
+				kind = spanBreak
+			}
+			if ref, ok := open[ev.Chef][kind]; ok {
+				ref.span().End = ev.Time
+				delete(open[ev.Chef], kind)
+			}
+		case evCookingStarted:
+			i := 0
+			for i < len(busy[ev.Station]) && busy[ev.Station][i] != 0 {
+				i++
+			}
+			lane := slot(ev.Station, i)
+			busy[ev.Station][i] = ev.Ticket
+			span := timelineSpan{Kind: spanCook, Start: ev.Time, Order: ev.Order, Ticket: ev.Ticket, Dish: ev.Dish,
+				Chef: ev.Chef, Station: ev.Station, Slot: lane.Slot}
+			cooking[ev.Ticket] = []spanRef{begin(chef(ev.Chef), span), begin(lane, span)}
+		case evCookingFinished:
+			for _, ref := range cooking[ev.Ticket] {
+				span := ref.span()
+				span.End, span.Wasted = ev.Time, ev.Reason == "wasted"
+			}
+			delete(cooking, ev.Ticket)
+			for i, ticket := range busy[ev.Station] {
+				if ticket == ev.Ticket {
+					busy[ev.Station][i] = 0
+				}
+			}
+		case evIncidentStarted:
+			inc := planned[ev.Incident]
+			if inc.Kind != incidentStationDown {
+				continue
+			}
+			for _, lane := range slots[inc.Station][inc.Capacity:] {
+				outages[inc.ID] = append(outages[inc.ID],
+					begin(lane, timelineSpan{Kind: spanOutage, Start: ev.Time, Station: inc.Station, Slot: lane.Slot}))
+			}
+		case evIncidentEnded:
+			for _, ref := range outages[ev.Incident] {
+				ref.span().End = ev.Time
+			}
+			delete(outages, ev.Incident)
+		}
+	}
+
+	// что не закончилось к концу журнала, тянется до последнего события
+	for _, lanes := range [][]*timelineLane{tl.Chefs, tl.Stations} {
+		for _, lane := range lanes {
+			for i := range lane.Spans {
+				if span := &lane.Spans[i]; span.End.IsZero() {
+					span.End = tl.End
+					span.Unfinished = span.Kind == spanCook
+				}
+			}
+		}
+	}
+	sort.SliceStable(tl.Chefs, func(i, j int) bool { return tl.Chefs[i].Chef < tl.Chefs[j].Chef })
+	return tl
+}
+
+// cooks — отрезки готовки на дорожке
+func (lane *timelineLane) cooks() []timelineSpan {
+	var spans []timelineSpan
+	for _, span := range lane.Spans {
+		if span.Kind == spanCook {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func (span timelineSpan) title() string {
+	switch span.Kind {
+	case spanBreak:
+		return fmt.Sprintf("Повар %d: перерыв %s–%s", span.Chef, formatTime(span.Start), formatTime(span.End))
+	case spanDuty:
+		return fmt.Sprintf("Повар %d: смена %s–%s", span.Chef, formatTime(span.Start), formatTime(span.End))
+	case spanOutage:
+		return fmt.Sprintf("%s, место %d: не работает %s–%s", span.Station, span.Slot,
+			formatTime(span.Start), formatTime(span.End))
+	}
+	title := fmt.Sprintf("Заказ #%d: %s\n%s, место %d · Повар %d\n%s–%s (%s)", span.Order, span.Dish,
+		span.Station, span.Slot, span.Chef, formatTime(span.Start), formatTime(span.End),
+		span.End.Sub(span.Start).Round(time.Minute))
+	if span.Wasted {
+		title += " — впустую, гости ушли"
+	}
+	if span.Unfinished {
+		title += " — не доготовлено"
+	}
+	return title
+}
+
+var stationColors = map[string]string{
+	stationGrill:  "#e4572e",
+	stationStove:  "#f3a712",
+	stationCold:   "#4ea5d9",
+	stationPastry: "#b57bd6",
+}
+
+func stationColor(name string) string {
+	if c, ok := stationColors[name]; ok {
+		return c
+	}
+	return "#888"
+}
+
+const (
+	timelineLabelWidth = 170
+	timelinePxPerMin   = 3.0
+	timelineRow        = 22
+)
+
+// svg рисует диаграмму Ганта: сверху шкала часов, ниже дорожки поваров и станций
+func (tl *kitchenTimeline) svg() string {
+	start := tl.Open.Truncate(time.Hour)
+	end := tl.End
+	if end.Before(tl.Close) {
+		end = tl.Close
+	}
+	end = end.Truncate(time.Hour).Add(time.Hour)
+	x := func(t time.Time) float64 {
+		return timelineLabelWidth + t.Sub(start).Minutes()*timelinePxPerMin
+	}
+	width := x(end) + 10
+	rows := len(tl.Chefs) + len(tl.Stations) + 2 // плюс заголовки групп
+	height := 30 + rows*timelineRow + 40
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%d" font-family="sans-serif" font-size="11">`+"\n",
+		width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#fff"/>`+"\n")
+	for t := start; !t.After(end); t = t.Add(30 * time.Minute) {
+		stroke := "#eee"
+		if t.Minute() == 0 {
+			stroke = "#ccc"
+			fmt.Fprintf(&b, `<text x="%.1f" y="16" text-anchor="middle" fill="#555">%s</text>`+"\n", x(t), formatTime(t))
+		}
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="22" x2="%.1f" y2="%d" stroke="%s"/>`+"\n", x(t), x(t), height-40, stroke)
+	}
+
+	y := 30
+	group := func(title string, lanes []*timelineLane) {
+		fmt.Fprintf(&b, `<text x="4" y="%d" font-weight="bold">%s</text>`+"\n", y+15, html.EscapeString(title))
+		y += timelineRow
+		for _, lane := range lanes {
+			fmt.Fprintf(&b, `<text x="12" y="%d">%s</text>`+"\n", y+15, html.EscapeString(lane.Title))
+			fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%.1f" y2="%d" stroke="#f4f4f4"/>`+"\n",
+				timelineLabelWidth, y+timelineRow, width, y+timelineRow)
+			// смены и поломки — фон дорожки, поверх них готовка и перерывы
+			for _, span := range lane.Spans {
+				if span.Kind != spanDuty && span.Kind != spanOutage {
+					continue
+				}
+				fill := "#eef6ea"
+				if span.Kind == spanOutage {
+					fill = "#f8d4d4"
+				}
+				fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"><title>%s</title></rect>`+"\n",
+					x(span.Start), y+1, math.Max(x(span.End)-x(span.Start), 1), timelineRow-2, fill,
+					html.EscapeString(span.title()))
+			}
+			for _, span := range lane.Spans {
+				if span.Kind != spanCook && span.Kind != spanBreak {
+					continue
+				}
+				x1, x2 := x(span.Start), x(span.End)
+				w := math.Max(x2-x1, 1)
+				style, text := `fill="#ccc"`, "#fff"
+				if span.Kind == spanCook {
+					style = fmt.Sprintf(`fill="%s"`, stationColor(span.Station))
+					if span.Wasted {
+						style += ` fill-opacity="0.35" stroke="#333" stroke-dasharray="2 2"`
+						text = "#333"
+					}
+					if span.Unfinished {
+						style += ` stroke="#000"`
+					}
+				}
+				fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" rx="2" %s><title>%s</title></rect>`+"\n",
+					x1, y+4, w, timelineRow-8, style, html.EscapeString(span.title()))
+				if span.Kind == spanCook && w >= 24 {
+					fmt.Fprintf(&b, `<text x="%.1f" y="%d" fill="%s" pointer-events="none">#%d</text>`+"\n",
+						x1+3, y+15, text, span.Order)
+				}
+			}
+			y += timelineRow
+		}
+	}
+	group("Повара", tl.Chefs)
+	group("Станции", tl.Stations)
+
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="22" x2="%.1f" y2="%d" stroke="#c33" stroke-dasharray="4 3"/>`+"\n",
+		x(tl.Close), x(tl.Close), y)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" fill="#c33">закрытие</text>`+"\n", x(tl.Close)+3, y+12)
+
+	lx := float64(timelineLabelWidth)
+	legend := func(style, title string) {
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="14" height="10" %s/>`+"\n", lx, height-22, style)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`+"\n", lx+18, height-13, html.EscapeString(title))
+		lx += 30 + float64(len([]rune(title)))*6.5
+	}
+	for _, st := range kitchenStations {
+		legend(fmt.Sprintf(`fill="%s"`, stationColor(st.Name)), st.Name)
+	}
+	legend(`fill="#888" fill-opacity="0.35" stroke="#333" stroke-dasharray="2 2"`, "впустую")
+	legend(`fill="#ccc"`, "перерыв")
+	legend(`fill="#eef6ea"`, "на смене")
+	legend(`fill="#f8d4d4"`, "поломка")
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// html — самодостаточная страница с диаграммой; подробности — во всплывающих подсказках
+func (tl *kitchenTimeline) html() string {
+	cooked, wasted := 0, 0
+	for _, lane := range tl.Chefs {
+		for _, span := range lane.cooks() {
+			cooked++
+			if span.Wasted {
+				wasted++
+			}
+		}
+	}
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Кухня: хронология смены</title>
+<style>
+body { font-family: sans-serif; margin: 20px; background: #fafafa; }
+.chart { overflow-x: auto; background: #fff; border: 1px solid #ddd; border-radius: 6px; }
+rect:hover { stroke: #000; stroke-width: 1.5; }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&b, "<h1>Кухня: %s — %s</h1>\n", formatTime(tl.Open), formatTime(tl.Close))
+	fmt.Fprintf(&b, "<p>Seed %d. Блюд приготовлено: %d, из них впустую: %d. Наведите на отрезок, чтобы увидеть заказ, блюдо и время.</p>\n",
+		tl.Seed, cooked, wasted)
+	b.WriteString(`<div class="chart">` + "\n")
+	b.WriteString(tl.svg())
+	b.WriteString("</div>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// traceEvent — событие формата Chrome Trace Event: X — отрезок, M — имена дорожек
+type traceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	TS   int64          `json:"ts"` // микросекунды виртуального времени от открытия
+	Dur  int64          `json:"dur,omitempty"`
+	PID  int            `json:"pid"`
+	TID  int            `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// trace собирает хронологию для about:tracing и Perfetto: процесс 1 —
+// повара, процесс 2 — места на станциях
+func (tl *kitchenTimeline) trace() ([]byte, error) {
+	var events []traceEvent
+	meta := func(name string, pid, tid int, value string) {
+		events = append(events, traceEvent{Name: name, Ph: "M", PID: pid, TID: tid, Args: map[string]any{"name": value}})
+	}
+	meta("process_name", 1, 0, "Повара")
+	meta("process_name", 2, 0, "Станции")
+	for pid, lanes := range [][]*timelineLane{tl.Chefs, tl.Stations} {
+		for i, lane := range lanes {
+			tid := i + 1
+			meta("thread_name", pid+1, tid, lane.Title)
+			events = append(events, traceEvent{Name: "thread_sort_index", Ph: "M", PID: pid + 1, TID: tid,
+				Args: map[string]any{"sort_index": tid}})
+			for _, span := range lane.Spans {
+				ev := traceEvent{Cat: span.Kind, Ph: "X", PID: pid + 1, TID: tid,
+					TS: span.Start.Sub(tl.Open).Microseconds(), Dur: span.End.Sub(span.Start).Microseconds()}
+				switch span.Kind {
+				case spanDuty:
+					ev.Name = "смена"
+				case spanBreak:
+					ev.Name = "перерыв"
+				case spanOutage:
+					ev.Name = "поломка"
+				default:
+					ev.Name = fmt.Sprintf("#%d %s", span.Order, span.Dish)
+					ev.Args = map[string]any{"order": span.Order, "ticket": span.Ticket, "dish": span.Dish,
+						"chef": span.Chef, "station": span.Station, "slot": span.Slot,
+						"start": formatTime(span.Start), "end": formatTime(span.End)}
+					if span.Wasted {
+						ev.Args["wasted"] = true
+					}
+					if span.Unfinished {
+						ev.Args["unfinished"] = true
+					}
+				}
+				events = append(events, ev)
+			}
+		}
+	}
+	return json.MarshalIndent(map[string]any{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+		"otherData":       map[string]any{"open": tl.Open, "close": tl.Close, "seed": tl.Seed},
+	}, "", " ")
+}
+
+func writeTimeline(tl *kitchenTimeline, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("ошибка при создании каталога: %v", err)
+	}
+	trace, err := tl.trace()
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации trace.json: %v", err)
+	}
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"timeline.svg", []byte(tl.svg())},
+		{"timeline.html", []byte(tl.html())},
+		{"trace.json", trace},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.name), f.data, 0o644); err != nil {
+			return fmt.Errorf("ошибка при записи %s: %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+func saveTimeline(events []Event, dir string) {
+	if dir == "-" || dir == "" {
+		return
+	}
+	if err := writeTimeline(buildTimeline(events), dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Хронология кухни сохранена в %s\n", dir)
+}
+
+// runTimeline строит хронологию кухни по сохранённому журналу:
+// go run . timeline events.jsonl [каталог]
+func runTimeline(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Использование: go run . timeline <журнал.jsonl> [каталог]")
+		os.Exit(2)
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка при открытии журнала: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	events, err := readEvents(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(1)
+	}
+	dir := "timeline"
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	saveTimeline(events, dir)
+}
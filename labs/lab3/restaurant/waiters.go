@@ -0,0 +1,183 @@
+package main
+
+import "sync/atomic"
+
+// === Логика официантов ===
+
+func waiter(m *StaffMember, r *Restaurant) {
+	waiterID := m.ID
+	f := r.floor
+	if !r.beginShift(m) {
+		return
+	}
+	for {
+		r.setWaiterTask(waiterID, "")
+		if !r.stayOnDuty(m) {
+			return
+		}
+		task, res := m.tasks.GetUntil(r.dutyDeadline(m))
+		if res == waitTimeout {
+			continue
+		}
+		if res == waitClosed {
+			r.endShift(m)
+			return
+		}
+		p := task.party
+		r.setWaiterTask(waiterID, task.String())
+
+		switch task.kind {
+		case taskTakeOrder:
+			if p.state == partyLeft {
+				continue
+			}
+			busy := randDuration(r.rng, minTakeOrderTime, maxTakeOrderTime)
+			r.clock.Sleep(busy)
+			now := r.clock.now
+			if p.state == partyLeft {
+				continue
+			}
+			if r.interrupted || !now.Before(r.closeTime.Add(-lastOrdersBeforeClose)) {
+				r.logf("[%s] Официант %d не принял заказ стола %d — кухня закрывается\n",
+					formatTime(now), waiterID, task.table.ID)
+				r.emit(Event{Type: evOrderSkipped, Party: p.ID, Table: task.table.ID, Waiter: waiterID,
+					Reason: "closing", Busy: busy})
+				p.state = partyLeft
+				p.cond.Signal()
+				continue
+			}
+
+			order := &Order{
+				WaiterID:  waiterID,
+				TableID:   task.table.ID,
+				SeatedAt:  p.SeatedAt,
+				StartTime: now,
+				VIP:       task.table.VIP,
+				party:     p,
+			}
+			// основное берёт каждый гость, закуску и десерт — по желанию
+			soldOut := false
+			for _, course := range courses {
+				if !r.onMenu(course) {
+					continue
+				}
+				var group []Dish
+				for j := 0; j < p.Size && !soldOut; j++ {
+					switch course {
+					case courseStarter:
+						if r.rng.Float64() >= r.cfg.Courses.StarterChance {
+							continue
+						}
+					case courseDessert:
+						if r.rng.Float64() >= r.cfg.Courses.DessertChance {
+							continue
+						}
+					}
+					dish, ok := r.chooseDish(course, p, waiterID)
+					if !ok {
+						soldOut = course == courseMain
+						continue
+					}
+					r.reserveDish(dish)
+					group = append(group, dish)
+					order.Dishes = append(order.Dishes, dish)
+					order.Price += dish.BasePrice
+				}
+				if len(group) > 0 {
+					order.courses = append(order.courses, group)
+				}
+			}
+			if soldOut {
+				for _, dish := range order.Dishes {
+					r.releaseDish(dish)
+				}
+				lost := expectedCheck(r.menu, p.Size)
+				r.logf("[%s] Гости стола %d ушли: заказанных блюд нет в наличии (потеряно %.2f руб.)\n",
+					formatTime(now), task.table.ID, lost)
+				r.emit(Event{Type: evPartyAbandoned, Party: p.ID, Table: task.table.ID, Waiter: waiterID,
+					Amount: lost, Reason: "sold_out"})
+				p.state = partyLeft
+				p.cond.Signal()
+				continue
+			}
+			order.OrderID = int(atomic.AddInt32(&r.orderIDCounter, 1))
+			p.Order = order
+			p.state = partyOrdered
+
+			r.logf("[%s] Официант %d принял заказ #%d от стола %d: %d блюд на %.2f руб.\n",
+				formatTime(now), waiterID, order.OrderID, order.TableID, len(order.Dishes), order.Price)
+			placed := Event{
+				Type:   evOrderPlaced,
+				Order:  order.OrderID,
+				Party:  p.ID,
+				Table:  order.TableID,
+				Waiter: waiterID,
+				Amount: order.Price,
+				VIP:    order.VIP,
+				Busy:   busy,
+			}
+			for _, dish := range order.Dishes {
+				placed.Dishes = append(placed.Dishes, dish.Name)
+			}
+			r.emit(placed)
+			r.fireCourse(order)
+
+		case taskDeliver:
+			if p.Order.cancelled {
+				continue
+			}
+			busy := randDuration(r.rng, minDeliveryTime, maxDeliveryTime)
+			r.clock.Sleep(busy)
+			order := p.Order
+			if order.cancelled {
+				r.logf("[%s] Официант %d принёс заказ #%d, но гости уже ушли\n",
+					formatTime(r.clock.now), waiterID, order.OrderID)
+				r.emit(Event{Type: evOrderReturned, Order: order.OrderID, Table: order.TableID, Waiter: waiterID,
+					Busy: busy})
+				continue
+			}
+			served := Event{Type: evCourseServed, Order: order.OrderID, Table: order.TableID, Waiter: waiterID,
+				Course: order.courseName()}
+			if order.course == 0 {
+				order.EndTime = r.clock.now
+				r.emit(Event{Type: evOrderDelivered, Order: order.OrderID, Table: order.TableID, Waiter: waiterID,
+					Busy: busy})
+				r.logf("[%s] Официант %d подал заказ #%d на стол %d за %v\n",
+					formatTime(order.EndTime), waiterID, order.OrderID, order.TableID, order.EndTime.Sub(order.StartTime))
+			} else {
+				served.Busy = busy
+				r.logf("[%s] Официант %d подал %s заказа #%d на стол %d\n",
+					formatTime(r.clock.now), waiterID, courseTitles[order.courseName()], order.OrderID, order.TableID)
+			}
+			r.emit(served)
+			p.state = partyServed
+			p.cond.Signal()
+
+		case taskBill:
+			busy := randDuration(r.rng, minPayTime, maxPayTime)
+			r.clock.Sleep(busy)
+			check := r.bill(p)
+			r.logf("[%s] Стол %d оплатил счёт на %.2f руб. (скидки %.2f, чаевые %.2f, платежей: %d)\n",
+				formatTime(r.clock.now), task.table.ID, check.Total, check.Discounts, check.Tip, len(check.Payments))
+			r.emit(Event{Type: evBillPaid, Party: p.ID, Table: task.table.ID, Waiter: waiterID,
+				Amount: check.Total, Busy: busy, Check: check})
+			p.state = partyPaid
+			p.cond.Signal()
+
+		case taskClear:
+			busy := randDuration(r.rng, minClearTime, maxClearTime)
+			r.clock.Sleep(busy)
+			now := r.clock.now
+			r.emit(Event{Type: evTableCleared, Party: p.ID, Table: task.table.ID, Waiter: waiterID, Busy: busy})
+			task.table.Party = nil
+			if w := p.Waiter; w != nil {
+				w.tables--
+			}
+			r.logf("[%s] Официант %d убрал стол %d\n", formatTime(now), waiterID, task.table.ID)
+			r.seatWaiting()
+			if !f.busy() {
+				f.empty.Broadcast()
+			}
+		}
+	}
+}